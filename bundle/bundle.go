@@ -0,0 +1,272 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/Khellendros97/khutils/catalog"
+	"gopkg.in/yaml.v3"
+)
+
+//Bundle 多语言消息包，每个语言的消息从嵌套的JSON/YAML/TOML文件加载，
+//并展开为以.分隔的键，例如{"menu": {"file": {"open": "Open"}}}展开为menu.file.open
+type Bundle struct {
+	DefaultLocale string // 回退链的最后一环
+
+	mu       sync.RWMutex
+	messages map[string]map[string]string
+}
+
+func NewBundle(defaultLocale string) *Bundle {
+	return &Bundle{
+		DefaultLocale: NormalizeLocale(defaultLocale),
+		messages:      make(map[string]map[string]string),
+	}
+}
+
+//NormalizeLocale 统一语言标签的分隔符，zh_Hant_TW => zh-Hant-TW
+func NormalizeLocale(locale string) string {
+	return strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+}
+
+//FallbackChain 返回语言标签的回退链，例如zh-Hant-TW => zh-Hant, zh，最后是默认语言
+func FallbackChain(locale string, defaultLocale string) []string {
+	locale = NormalizeLocale(locale)
+	defaultLocale = NormalizeLocale(defaultLocale)
+	chain := make([]string, 0, 4)
+	for locale != "" {
+		chain = append(chain, locale)
+		i := strings.LastIndexByte(locale, '-')
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	if defaultLocale != "" {
+		for _, l := range chain {
+			if strings.EqualFold(l, defaultLocale) {
+				return chain
+			}
+		}
+		chain = append(chain, defaultLocale)
+	}
+	return chain
+}
+
+//AddMessages 添加一个语言的嵌套消息，已存在的键会被覆盖
+func (b *Bundle) AddMessages(locale string, nested map[string]any) error {
+	flat := make(map[string]string)
+	if err := flatten("", nested, flat); err != nil {
+		return err
+	}
+	locale = NormalizeLocale(locale)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	messages := b.messages[locale]
+	if messages == nil {
+		messages = make(map[string]string)
+		b.messages[locale] = messages
+	}
+	for k, v := range flat {
+		messages[k] = v
+	}
+	return nil
+}
+
+//SetMessage 设置单条消息
+func (b *Bundle) SetMessage(locale string, key string, pattern string) {
+	locale = NormalizeLocale(locale)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	messages := b.messages[locale]
+	if messages == nil {
+		messages = make(map[string]string)
+		b.messages[locale] = messages
+	}
+	messages[key] = pattern
+}
+
+func flatten(prefix string, value any, out map[string]string) error {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		for k, item := range v {
+			if err := flatten(join(k), item, out); err != nil {
+				return err
+			}
+		}
+	case map[any]any:
+		for k, item := range v {
+			if err := flatten(join(fmt.Sprint(k)), item, out); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, item := range v {
+			if err := flatten(join(strconv.Itoa(i)), item, out); err != nil {
+				return err
+			}
+		}
+	case []map[string]any: // TOML的表数组
+		for i, item := range v {
+			if err := flatten(join(strconv.Itoa(i)), item, out); err != nil {
+				return err
+			}
+		}
+	case string:
+		out[prefix] = v
+	case nil:
+		out[prefix] = ""
+	default:
+		out[prefix] = fmt.Sprint(v)
+	}
+	return nil
+}
+
+//Parse 按格式（json、yaml、yml、toml）解析消息文件的内容并添加到locale
+func (b *Bundle) Parse(locale string, format string, data []byte) error {
+	nested := make(map[string]any)
+	var err error
+	switch strings.ToLower(strings.TrimPrefix(format, ".")) {
+	case "json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&nested)
+	case "yaml", "yml":
+		err = yaml.Unmarshal(data, &nested)
+	case "toml":
+		err = toml.Unmarshal(data, &nested)
+	default:
+		return fmt.Errorf("unknown bundle format: %s", format)
+	}
+	if err != nil {
+		return fmt.Errorf("parse %s bundle for %s: %w", format, locale, err)
+	}
+	return b.AddMessages(locale, nested)
+}
+
+//localeOf 从文件名推断语言，例如locales/zh-Hant.yaml => zh-Hant
+func localeOf(name string) (locale string, ext string) {
+	base := path.Base(filepath.ToSlash(name))
+	ext = path.Ext(base)
+	return strings.TrimSuffix(base, ext), ext
+}
+
+//LoadFile 加载消息文件，语言由文件名决定
+func (b *Bundle) LoadFile(name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	locale, ext := localeOf(name)
+	return b.Parse(locale, ext, data)
+}
+
+//LoadFS 加载文件系统（例如embed.FS）中匹配pattern的所有消息文件，语言由文件名决定
+//例如LoadFS(locales, "locales/*.json")
+func (b *Bundle) LoadFS(fsys fs.FS, pattern string) error {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no bundle file matches %s", pattern)
+	}
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		locale, ext := localeOf(name)
+		if err := b.Parse(locale, ext, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Locales 返回已加载的所有语言
+func (b *Bundle) Locales() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	locales := make([]string, 0, len(b.messages))
+	for locale := range b.messages {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+//Keys 返回一个语言的所有键（不含回退）
+func (b *Bundle) Keys(locale string) []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	messages := b.messages[NormalizeLocale(locale)]
+	keys := make([]string, 0, len(messages))
+	for key := range messages {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//Lookup 沿回退链查找消息
+//@return pattern 消息; found 找到消息的语言，未找到时为空
+func (b *Bundle) Lookup(locale string, key string) (pattern string, found string) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, l := range FallbackChain(locale, b.DefaultLocale) {
+		if messages, ok := b.messages[l]; ok {
+			if pattern, ok := messages[key]; ok {
+				return pattern, l
+			}
+		}
+	}
+	return "", ""
+}
+
+//Catalog 导出一个语言的消息（不含回退的消息）
+func (b *Bundle) Catalog(locale string) *catalog.Catalog {
+	locale = NormalizeLocale(locale)
+	c := catalog.New(locale)
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	messages := b.messages[locale]
+	keys := make([]string, 0, len(messages))
+	for key := range messages {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		c.Set(key, messages[key])
+	}
+	return c
+}
+
+//AddCatalog 将翻译目录中的消息添加到目录的语言，复数形式展开为key.one、key.other等键
+func (b *Bundle) AddCatalog(c *catalog.Catalog) {
+	for _, m := range c.Messages() {
+		if len(m.Plurals) == 0 {
+			b.SetMessage(c.Locale, m.Key, m.Text)
+			continue
+		}
+		for category, text := range m.Plurals {
+			b.SetMessage(c.Locale, m.Key+"."+string(category), text)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Khellendros97/khutils/catalog"
+)
+
+//jsonNode 按键的添加顺序输出的嵌套对象
+type jsonNode struct {
+	keys     []string
+	children map[string]*jsonNode
+	text     *string
+}
+
+func (n *jsonNode) child(key string) *jsonNode {
+	if n.children == nil {
+		n.children = make(map[string]*jsonNode)
+	}
+	c, ok := n.children[key]
+	if !ok {
+		c = &jsonNode{}
+		n.children[key] = c
+		n.keys = append(n.keys, key)
+	}
+	return c
+}
+
+func (n *jsonNode) write(buf *bytes.Buffer, indent string) {
+	if n.text != nil {
+		writeJSONString(buf, *n.text)
+		return
+	}
+	buf.WriteString("{")
+	for i, key := range n.keys {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n" + indent + "  ")
+		writeJSONString(buf, key)
+		buf.WriteString(": ")
+		n.children[key].write(buf, indent+"  ")
+	}
+	if len(n.keys) > 0 {
+		buf.WriteString("\n" + indent)
+	}
+	buf.WriteString("}")
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	buf.Truncate(buf.Len() - 1) // 去掉Encode添加的换行
+}
+
+//WriteJSON 将翻译目录按键中的.展开为嵌套的JSON对象写出，与LoadFile读取的格式相同
+//复数形式写作{"one": "...", "other": "..."}子对象
+func WriteJSON(w io.Writer, c *catalog.Catalog) error {
+	root := &jsonNode{}
+	set := func(key string, text string) error {
+		n := root
+		for _, part := range strings.Split(key, ".") {
+			if n.text != nil {
+				return fmt.Errorf("key conflict: %s", key)
+			}
+			n = n.child(part)
+		}
+		if n.text != nil || len(n.keys) > 0 {
+			return fmt.Errorf("key conflict: %s", key)
+		}
+		n.text = &text
+		return nil
+	}
+	for _, m := range c.Messages() {
+		if len(m.Plurals) == 0 {
+			if err := set(m.Key, m.Text); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, category := range m.PluralCategories() {
+			if err := set(m.Key+"."+string(category), m.Plurals[category]); err != nil {
+				return err
+			}
+		}
+	}
+	var buf bytes.Buffer
+	root.write(&buf, "")
+	buf.WriteString("\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
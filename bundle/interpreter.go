@@ -0,0 +1,55 @@
+package bundle
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/Khellendros97/khutils/format"
+)
+
+//Interpreter 基于消息包的表达式解释器，{{Lang::menu.file.open}}按.分隔的路径查找消息，
+//当前语言缺少该键时沿回退链查找。消息本身会作为Fmt的格式化字符串，使用调用时的实参渲染：
+//消息"Hello, {0}"配合{{Lang::greeting($0)}}使用。消息之间的引用与模板一样受MaxIncludeDepth限制
+type Interpreter struct {
+	Bundle *Bundle
+	locale atomic.Value
+}
+
+//Interpreter 返回使用指定语言的解释器
+func (b *Bundle) Interpreter(locale string) *Interpreter {
+	i := &Interpreter{Bundle: b}
+	i.SetLocale(locale)
+	return i
+}
+
+//Register 将使用指定语言的解释器注册到命名空间，命名空间为tpl时返回错误
+func (b *Bundle) Register(namespace string, locale string) (*Interpreter, error) {
+	i := b.Interpreter(locale)
+	if err := format.RegisterInterpreter(namespace, i); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+//SetLocale 切换解释器使用的语言
+func (i *Interpreter) SetLocale(locale string) {
+	i.locale.Store(NormalizeLocale(locale))
+}
+
+//Locale 返回解释器当前使用的语言
+func (i *Interpreter) Locale() string {
+	locale, _ := i.locale.Load().(string)
+	return locale
+}
+
+func (i *Interpreter) Pattern(key string) (string, error) {
+	pattern, found := i.Bundle.Lookup(i.Locale(), key)
+	if found == "" {
+		return "", fmt.Errorf("%w: %s", format.ErrKeyNotFound, key)
+	}
+	return pattern, nil
+}
+
+func (i *Interpreter) Format(key string, args []any) (string, error) {
+	return format.FormatPattern(i, key, args)
+}
@@ -0,0 +1,131 @@
+package catalog
+
+import (
+	"sort"
+
+	"github.com/Khellendros97/khutils/plural"
+)
+
+//Message 翻译目录中的一条消息
+type Message struct {
+	Key       string                     // 消息的键，例如menu.file.open或gettext的msgid
+	Context   string                     // 消息的上下文（gettext的msgctxt），没有时为空
+	Text      string                     // 消息文本，含复数形式时为other形式
+	Plurals   map[plural.Category]string // 复数形式，没有时为nil
+	Comment   string                     // 给翻译人员的注释
+	Locations []string                   // 消息在源码中的位置，例如main.go:12
+}
+
+//ID 消息在目录中的唯一标识
+func (m *Message) ID() string {
+	return ID(m.Context, m.Key)
+}
+
+//ID 返回上下文和键组成的唯一标识
+func ID(context string, key string) string {
+	if context == "" {
+		return key
+	}
+	return context + "\x04" + key
+}
+
+//Texts 返回消息的所有文本（包括复数形式），用于检查占位符等
+func (m *Message) Texts() []string {
+	texts := []string{m.Text}
+	for _, category := range m.PluralCategories() {
+		if category != plural.Other || m.Plurals[category] != m.Text {
+			texts = append(texts, m.Plurals[category])
+		}
+	}
+	return texts
+}
+
+//categoryOrder 复数类别的排列顺序
+var categoryOrder = map[plural.Category]int{
+	plural.Zero: 0, plural.One: 1, plural.Two: 2, plural.Few: 3, plural.Many: 4, plural.Other: 5,
+}
+
+//PluralCategories 按zero、one、two、few、many、other的顺序返回消息含有的复数类别
+func (m *Message) PluralCategories() []plural.Category {
+	categories := make([]plural.Category, 0, len(m.Plurals))
+	for category := range m.Plurals {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		return categoryOrder[categories[i]] < categoryOrder[categories[j]]
+	})
+	return categories
+}
+
+//Catalog 一个语言的翻译目录，保持消息的添加顺序
+type Catalog struct {
+	Locale string
+
+	messages []*Message
+	index    map[string]*Message
+}
+
+func New(locale string) *Catalog {
+	return &Catalog{
+		Locale: locale,
+		index:  make(map[string]*Message),
+	}
+}
+
+//Add 添加消息，已存在相同标识的消息时将其替换
+func (c *Catalog) Add(m *Message) {
+	id := m.ID()
+	if old, ok := c.index[id]; ok {
+		*old = *m
+		return
+	}
+	c.index[id] = m
+	c.messages = append(c.messages, m)
+}
+
+//Set 设置键对应的文本
+func (c *Catalog) Set(key string, text string) *Message {
+	if m, ok := c.index[key]; ok {
+		m.Text = text
+		return m
+	}
+	m := &Message{Key: key, Text: text}
+	c.Add(m)
+	return m
+}
+
+//Get 返回键对应的消息，不存在时返回nil
+func (c *Catalog) Get(key string) *Message {
+	return c.index[key]
+}
+
+//GetContext 返回上下文和键对应的消息，不存在时返回nil
+func (c *Catalog) GetContext(context string, key string) *Message {
+	return c.index[ID(context, key)]
+}
+
+//Messages 按添加顺序返回所有消息
+func (c *Catalog) Messages() []*Message {
+	return c.messages
+}
+
+//Keys 返回排序后的所有消息标识
+func (c *Catalog) Keys() []string {
+	keys := make([]string, 0, len(c.messages))
+	for _, m := range c.messages {
+		keys = append(keys, m.ID())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *Catalog) Len() int {
+	return len(c.messages)
+}
+
+//Sort 按消息标识排序
+func (c *Catalog) Sort() {
+	sort.SliceStable(c.messages, func(i, j int) bool {
+		return c.messages[i].ID() < c.messages[j].ID()
+	})
+}
@@ -0,0 +1,165 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+//IssueKind 检查发现的问题类型
+type IssueKind string
+
+const (
+	ISSUE_MISSING      IssueKind = "missing"      // 目标语言缺少源语言中的消息
+	ISSUE_EXTRA        IssueKind = "extra"        // 目标语言中多出了源语言没有的消息
+	ISSUE_PLACEHOLDER  IssueKind = "placeholder"  // 译文与原文的占位符不一致
+	ISSUE_UNTRANSLATED IssueKind = "untranslated" // 译文为空或与原文相同
+)
+
+//Issue 检查发现的一个问题
+type Issue struct {
+	Kind    IssueKind
+	Locale  string // 目标语言
+	Key     string
+	Context string
+	Detail  string // 问题的说明，例如缺少的占位符
+}
+
+func (i Issue) String() string {
+	key := i.Key
+	if i.Context != "" {
+		key = i.Context + "::" + i.Key
+	}
+	s := fmt.Sprintf("%s: %s %s", i.Locale, i.Kind, key)
+	if i.Detail != "" {
+		s += ": " + i.Detail
+	}
+	return s
+}
+
+//LocaleReport 一个目标语言的检查结果
+type LocaleReport struct {
+	Locale     string
+	Total      int // 源语言的消息数量
+	Translated int // 已翻译（存在且与原文不同）的消息数量
+	Issues     []Issue
+}
+
+//Coverage 翻译覆盖率，0到1之间
+func (r *LocaleReport) Coverage() float64 {
+	if r.Total == 0 {
+		return 1
+	}
+	return float64(r.Translated) / float64(r.Total)
+}
+
+//Report 多个语言与源语言对比的检查结果
+type Report struct {
+	Source  string
+	Locales []*LocaleReport
+}
+
+//Issues 返回指定类型的问题，不指定类型时返回所有问题
+func (r *Report) Issues(kinds ...IssueKind) []Issue {
+	var issues []Issue
+	for _, l := range r.Locales {
+		for _, issue := range l.Issues {
+			if len(kinds) == 0 || containsKind(kinds, issue.Kind) {
+				issues = append(issues, issue)
+			}
+		}
+	}
+	return issues
+}
+
+func containsKind(kinds []IssueKind, kind IssueKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+//hasLetter 文本中是否有需要翻译的文字，只有占位符、数字和标点的消息与原文相同时不算未翻译
+func hasLetter(text string) bool {
+	last := 0
+	var sb strings.Builder
+	for _, span := range PlaceholderSpans(text) {
+		sb.WriteString(text[last:span[0]])
+		last = span[1]
+	}
+	sb.WriteString(text[last:])
+	for _, ch := range sb.String() {
+		if unicode.IsLetter(ch) {
+			return true
+		}
+	}
+	return false
+}
+
+//Check 将各个目标语言的目录与源语言对比，检查缺少和多出的消息、占位符不一致以及未翻译的消息
+//含复数形式的消息，other形式的占位符需要与原文一致，其他形式只检查多出的占位符（例如one形式可以省略数量）
+func Check(source *Catalog, targets ...*Catalog) *Report {
+	report := &Report{Source: source.Locale}
+	for _, target := range targets {
+		report.Locales = append(report.Locales, checkLocale(source, target))
+	}
+	return report
+}
+
+func checkLocale(source *Catalog, target *Catalog) *LocaleReport {
+	r := &LocaleReport{Locale: target.Locale, Total: source.Len()}
+	add := func(kind IssueKind, m *Message, detail string) {
+		r.Issues = append(r.Issues, Issue{Kind: kind, Locale: target.Locale, Key: m.Key, Context: m.Context, Detail: detail})
+	}
+	for _, src := range source.Messages() {
+		dst := target.GetContext(src.Context, src.Key)
+		if dst == nil {
+			add(ISSUE_MISSING, src, "")
+			continue
+		}
+		if dst.Text == "" && len(dst.Plurals) == 0 {
+			add(ISSUE_UNTRANSLATED, src, "empty translation")
+			continue
+		}
+		if dst.Text == src.Text && hasLetter(src.Text) {
+			add(ISSUE_UNTRANSLATED, src, "identical to source")
+		} else {
+			r.Translated++
+		}
+		missing, extra := DiffPlaceholders(src.Text, dst.Text)
+		if len(missing) > 0 || len(extra) > 0 {
+			add(ISSUE_PLACEHOLDER, src, placeholderDetail("", missing, extra))
+		}
+		for _, category := range dst.PluralCategories() {
+			text := dst.Plurals[category]
+			if text == dst.Text {
+				continue
+			}
+			if _, extra := DiffPlaceholders(src.Text, text); len(extra) > 0 {
+				add(ISSUE_PLACEHOLDER, src, placeholderDetail(string(category), nil, extra))
+			}
+		}
+	}
+	for _, dst := range target.Messages() {
+		if source.GetContext(dst.Context, dst.Key) == nil {
+			add(ISSUE_EXTRA, dst, "")
+		}
+	}
+	return r
+}
+
+func placeholderDetail(category string, missing []string, extra []string) string {
+	var parts []string
+	if category != "" {
+		parts = append(parts, "["+category+"]")
+	}
+	if len(missing) > 0 {
+		parts = append(parts, "missing "+strings.Join(missing, " "))
+	}
+	if len(extra) > 0 {
+		parts = append(parts, "extra "+strings.Join(extra, " "))
+	}
+	return strings.Join(parts, " ")
+}
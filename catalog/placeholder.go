@@ -0,0 +1,75 @@
+package catalog
+
+import (
+	"regexp"
+	"strings"
+)
+
+//printfVerb printf风格的占位符，例如%s、%.2f、%1$d，以及Apple的%@
+var printfVerb = regexp.MustCompile(`^%(\d+\$)?[-+#0]*(\d+|\*)?(\.(\d+|\*))?[a-zA-Z@]`)
+
+//Placeholders 按出现顺序返回文本中的占位符：format包的{0}、{}、{:%.2f}、{{...}}，以及printf风格的%s、%1$d
+func Placeholders(text string) []string {
+	spans := PlaceholderSpans(text)
+	placeholders := make([]string, len(spans))
+	for i, span := range spans {
+		placeholders[i] = text[span[0]:span[1]]
+	}
+	return placeholders
+}
+
+//PlaceholderSpans 按出现顺序返回文本中占位符的起止位置[start, end)
+func PlaceholderSpans(text string) [][2]int {
+	var spans [][2]int
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '{':
+			end := -1
+			if strings.HasPrefix(text[i:], "{{") {
+				if j := strings.Index(text[i+2:], "}}"); j >= 0 {
+					end = i + 2 + j + 2
+				}
+			} else if j := strings.IndexByte(text[i:], '}'); j >= 0 {
+				end = i + j + 1
+			}
+			if end < 0 {
+				return spans
+			}
+			spans = append(spans, [2]int{i, end})
+			i = end - 1
+		case '%':
+			if strings.HasPrefix(text[i:], "%%") {
+				i++
+				continue
+			}
+			if verb := printfVerb.FindString(text[i:]); verb != "" {
+				spans = append(spans, [2]int{i, i + len(verb)})
+				i += len(verb) - 1
+			}
+		}
+	}
+	return spans
+}
+
+//DiffPlaceholders 比较两段文本的占位符（不考虑顺序）
+//@return missing source中有而target中缺少的占位符; extra target中多出的占位符
+func DiffPlaceholders(source string, target string) (missing []string, extra []string) {
+	count := make(map[string]int)
+	for _, p := range Placeholders(source) {
+		count[p]++
+	}
+	for _, p := range Placeholders(target) {
+		if count[p] > 0 {
+			count[p]--
+		} else {
+			extra = append(extra, p)
+		}
+	}
+	for _, p := range Placeholders(source) {
+		if count[p] > 0 {
+			count[p]--
+			missing = append(missing, p)
+		}
+	}
+	return
+}
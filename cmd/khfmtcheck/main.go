@@ -0,0 +1,14 @@
+//khfmtcheck 检查format.Fmt调用的静态分析工具
+//单独运行：khfmtcheck ./...
+//通过go vet运行：go vet -vettool=$(which khfmtcheck) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/Khellendros97/khutils/fmtcheck"
+)
+
+func main() {
+	singlechecker.Main(fmtcheck.Analyzer)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Khellendros97/khutils/bundle"
+	"github.com/Khellendros97/khutils/catalog"
+	"github.com/Khellendros97/khutils/gettext"
+	"github.com/Khellendros97/khutils/mobile"
+	"github.com/Khellendros97/khutils/xliff"
+)
+
+//runCatalog khutils catalog <subcommand>
+func runCatalog(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: khutils catalog check [flags] files...")
+	}
+	switch args[0] {
+	case "check":
+		return runCatalogCheck(args[1:])
+	}
+	return fmt.Errorf("unknown subcommand: %s", args[0])
+}
+
+//localeFromPath 从路径推断语言：values-zh-rCN/strings.xml => zh-CN，zh.lproj/Localizable.strings => zh，
+//其他文件使用文件名，例如locales/zh.json => zh、app_zh.arb => zh
+func localeFromPath(path string) string {
+	dir := filepath.Base(filepath.Dir(path))
+	if locale, ok := strings.CutPrefix(dir, "values-"); ok {
+		return strings.Replace(locale, "-r", "-", 1)
+	}
+	if dir == "values" {
+		return "en"
+	}
+	if locale, ok := strings.CutSuffix(dir, ".lproj"); ok {
+		return locale
+	}
+	base := filepath.Base(path)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	if strings.EqualFold(filepath.Ext(path), ".arb") {
+		if i := strings.IndexByte(base, '_'); i >= 0 {
+			return base[i+1:]
+		}
+	}
+	return base
+}
+
+//loadCatalog 根据扩展名读取任意支持的格式，得到通用的翻译目录
+func loadCatalog(path string) (*catalog.Catalog, error) {
+	locale := localeFromPath(path)
+	ext := strings.ToLower(filepath.Ext(path))
+	read := func(fn func(r io.Reader) (*catalog.Catalog, error)) (*catalog.Catalog, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return fn(f)
+	}
+	var c *catalog.Catalog
+	var err error
+	switch ext {
+	case ".po", ".pot", ".mo":
+		var po *gettext.Catalog
+		if po, err = gettext.LoadFile(path); err == nil {
+			c = gettext.ToCatalog(po)
+		}
+	case ".json", ".yaml", ".yml", ".toml":
+		b := bundle.NewBundle(locale)
+		if err = b.LoadFile(path); err == nil {
+			c = b.Catalog(locale)
+		}
+	case ".xlf", ".xliff":
+		c, err = read(func(r io.Reader) (*catalog.Catalog, error) {
+			doc, err := xliff.Read(r)
+			if err != nil {
+				return nil, err
+			}
+			return doc.Catalog(), nil
+		})
+	case ".arb":
+		c, err = read(mobile.ReadARB)
+	case ".xml":
+		c, err = read(func(r io.Reader) (*catalog.Catalog, error) { return mobile.ReadAndroid(r, locale) })
+	case ".strings":
+		c, err = read(func(r io.Reader) (*catalog.Catalog, error) { return mobile.ReadStrings(r, locale) })
+	case ".stringsdict":
+		c, err = read(func(r io.Reader) (*catalog.Catalog, error) { return mobile.ReadStringsDict(r, locale) })
+	default:
+		return nil, fmt.Errorf("unknown catalog file: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if c.Locale == "" {
+		c.Locale = locale
+	}
+	c.Locale = bundle.NormalizeLocale(c.Locale)
+	return c, nil
+}
+
+//runCatalogCheck khutils catalog check [-source en] [-ignore kinds] files...
+//对比各个语言的翻译目录，发现问题时以非零状态退出，便于在CI中使用
+func runCatalogCheck(args []string) error {
+	flags := flag.NewFlagSet("catalog check", flag.ContinueOnError)
+	source := flags.String("source", "en", "source locale")
+	quiet := flags.Bool("q", false, "only print the summary")
+	var ignore listFlag
+	flags.Var(&ignore, "ignore", "issue kinds to ignore: missing, extra, placeholder, untranslated")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("no catalog files")
+	}
+
+	// 同一语言的多个文件（例如.strings和.stringsdict）合并为一个目录
+	catalogs := make(map[string]*catalog.Catalog)
+	var locales []string
+	for _, path := range flags.Args() {
+		c, err := loadCatalog(path)
+		if err != nil {
+			return err
+		}
+		merged, ok := catalogs[c.Locale]
+		if !ok {
+			catalogs[c.Locale] = c
+			locales = append(locales, c.Locale)
+			continue
+		}
+		for _, m := range c.Messages() {
+			merged.Add(m)
+		}
+	}
+	sourceCatalog, ok := catalogs[bundle.NormalizeLocale(*source)]
+	if !ok {
+		return fmt.Errorf("source locale %s not found", *source)
+	}
+	var targets []*catalog.Catalog
+	for _, locale := range locales {
+		if locale != sourceCatalog.Locale {
+			targets = append(targets, catalogs[locale])
+		}
+	}
+
+	report := catalog.Check(sourceCatalog, targets...)
+	count := 0
+	for _, l := range report.Locales {
+		var issues []catalog.Issue
+		for _, issue := range l.Issues {
+			if !ignore.contains(string(issue.Kind)) {
+				issues = append(issues, issue)
+			}
+		}
+		count += len(issues)
+		fmt.Printf("%s: %d/%d translated (%.1f%%), %d issues\n", l.Locale, l.Translated, l.Total, l.Coverage()*100, len(issues))
+		if *quiet {
+			continue
+		}
+		for _, issue := range issues {
+			fmt.Println("  " + issue.String())
+		}
+	}
+	if count > 0 {
+		return fmt.Errorf("%d issues found", count)
+	}
+	return nil
+}
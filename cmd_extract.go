@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Khellendros97/khutils/bundle"
+	"github.com/Khellendros97/khutils/catalog"
+	"github.com/Khellendros97/khutils/extract"
+	"github.com/Khellendros97/khutils/gettext"
+	"github.com/Khellendros97/khutils/xliff"
+)
+
+//listFlag 可以重复指定、也可以用逗号分隔的参数
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) contains(value string) bool {
+	for _, item := range *l {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *listFlag) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+//runExtract khutils extract [-format pot|json|xliff] [-o file] [-func pkg.Func[:index]] [-namespace ns] [paths]
+//提取源码中格式化字符串引用的变量和函数，生成翻译模板
+func runExtract(args []string) error {
+	flags := flag.NewFlagSet("extract", flag.ContinueOnError)
+	outFormat := flags.String("format", "pot", "output format: pot, json or xliff")
+	output := flags.String("o", "", "output file, defaults to stdout")
+	locale := flags.String("locale", "en", "source locale of the template")
+	sorted := flags.Bool("sort", false, "sort messages by namespace and key")
+	var funcs, namespaces listFlag
+	flags.Var(&funcs, "func", "extra function whose argument is a pattern, as pkg.Func[:argIndex]")
+	flags.Var(&namespaces, "namespace", "only extract these namespaces")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	e, err := extract.NewExtractor(append(append([]string{}, extract.DefaultFuncs...), funcs...)...)
+	if err != nil {
+		return err
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"./..."}
+	}
+	for _, path := range paths {
+		if err := e.AddPath(path); err != nil {
+			return err
+		}
+	}
+	for _, err := range e.Errors {
+		fmt.Fprintln(os.Stderr, "warning:", err)
+	}
+	c := e.Catalog(*locale, namespaces...)
+	if *sorted {
+		c.Sort()
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	switch *outFormat {
+	case "pot", "po":
+		po, err := gettext.FromCatalog(c, "")
+		if err != nil {
+			return err
+		}
+		// 模板不声明语言，且消息未翻译
+		delete(po.Header, "Language")
+		for _, m := range po.Messages() {
+			m.Str = []string{""}
+		}
+		return gettext.WritePO(w, po)
+	case "json":
+		// 消息包按命名空间分别注册，JSON模板中不区分命名空间
+		keys := catalog.New(c.Locale)
+		for _, m := range c.Messages() {
+			if keys.Get(m.Key) == nil {
+				keys.Set(m.Key, "")
+			}
+		}
+		return bundle.WriteJSON(w, keys)
+	case "xliff":
+		return xliff.Export(c, nil, xliff.VERSION_12).Write(w)
+	}
+	return fmt.Errorf("unknown format: %s", *outFormat)
+}
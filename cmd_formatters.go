@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Khellendros97/khutils/format"
+)
+
+//formatterParam 参数声明的JSON形式
+type formatterParam struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Optional bool   `json:"optional,omitempty"`
+	Variadic bool   `json:"variadic,omitempty"`
+	Default  any    `json:"default,omitempty"`
+	Doc      string `json:"doc,omitempty"`
+}
+
+//formatterEntry 格式化器的JSON形式
+type formatterEntry struct {
+	Name   string           `json:"name,omitempty"`
+	Label  string           `json:"label,omitempty"`
+	Params []formatterParam `json:"params,omitempty"`
+}
+
+//runFormatters khutils formatters [-json]
+//列出format包中注册的格式化器：单字符标签，以及命名格式化器和它们的参数
+func runFormatters(args []string) error {
+	flags := flag.NewFlagSet("formatters", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the registry as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	var entries []formatterEntry
+	for _, info := range format.Formatters() {
+		entry := formatterEntry{Name: info.Name}
+		if info.Label != 0 {
+			entry.Label = string(info.Label)
+		}
+		for _, param := range info.Params {
+			entry.Params = append(entry.Params, formatterParam{
+				Name: param.Name, Kind: param.Kind.String(), Optional: param.Optional, Variadic: param.Variadic,
+				Default: param.Default.Interface(), Doc: param.Doc,
+			})
+		}
+		entries = append(entries, entry)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(entries)
+	}
+	for _, entry := range entries {
+		if entry.Label != "" {
+			fmt.Printf("{:%s}\n", entry.Label)
+			continue
+		}
+		params := make([]string, len(entry.Params))
+		for i, param := range entry.Params {
+			params[i] = param.Name
+			if param.Variadic {
+				params[i] += "..."
+			} else if param.Optional {
+				params[i] = "[" + params[i] + "]"
+			}
+		}
+		fmt.Printf("{:%s(%s)}\n", entry.Name, strings.Join(params, ", "))
+		for _, param := range entry.Params {
+			fmt.Printf("    %-10s %-8s %s\n", param.Name, param.Kind, param.Doc)
+		}
+	}
+	return nil
+}
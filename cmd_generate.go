@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Khellendros97/khutils/codegen"
+)
+
+//runGenerate khutils generate [-pkg msgs] [-namespace Lang] [-o msgs_gen.go] catalog
+//为源语言的翻译目录生成类型安全的访问函数，通常在go:generate中使用，例如：//go:generate go run github.com/Khellendros97/khutils generate -pkg msgs -namespace Lang -o msgs_gen.go ../locales/en.json
+func runGenerate(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	pkg := flags.String("pkg", "", "package name, defaults to $GOPACKAGE")
+	namespace := flags.String("namespace", "", "interpreter namespace of the messages, empty for the default interpreter")
+	output := flags.String("o", "", "output file, defaults to stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected one catalog file")
+	}
+	if *pkg == "" {
+		*pkg = os.Getenv("GOPACKAGE")
+	}
+	if *pkg == "" {
+		*pkg = "msgs"
+	}
+	c, err := loadCatalog(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	src, err := codegen.Generate(c, codegen.Config{Package: *pkg, Namespace: *namespace, Source: flags.Arg(0)})
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(*output, src, 0644)
+}
@@ -0,0 +1,369 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	goformat "go/format"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Khellendros97/khutils/catalog"
+	"github.com/Khellendros97/khutils/format"
+)
+
+//Config 代码生成的配置
+type Config struct {
+	Package   string // 生成的包名
+	Namespace string // 消息所在的表达式解释器命名空间，为空时使用默认解释器
+	Source    string // 源文件，只用于写入生成代码的注释
+}
+
+//Param 消息的一个参数
+type Param struct {
+	Name string
+	Type string // Go类型，例如int、float64、time.Time、any
+}
+
+//Func 为一条消息生成的访问函数
+type Func struct {
+	Name    string
+	Message *catalog.Message
+	Params  []Param
+}
+
+//FuncName 将消息的键转换为导出的函数名，例如menu.file.open => MenuFileOpen，带上下文时上下文作为前缀
+func FuncName(m *catalog.Message) string {
+	var sb strings.Builder
+	upper := true
+	for _, ch := range m.Context + "." + m.Key {
+		if !unicode.IsLetter(ch) && !unicode.IsDigit(ch) {
+			upper = true
+			continue
+		}
+		if upper {
+			ch = unicode.ToUpper(ch)
+			upper = false
+		}
+		sb.WriteRune(ch)
+	}
+	name := sb.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) || !token.IsExported(name) {
+		name = "M" + name
+	}
+	return name
+}
+
+//verbType 根据fmt动词推断参数类型
+func verbType(verb byte) string {
+	switch verb {
+	case 'd', 'o', 'O', 'b', 'c', 'U':
+		return "int"
+	case 'e', 'E', 'f', 'F', 'g', 'G':
+		return "float64"
+	case 's', 'q':
+		return "string"
+	case 't':
+		return "bool"
+	}
+	return "any"
+}
+
+//formatterArgCount 占位符使用的参数个数，见format.IMultiArgFormatter，在其他地方注册的格式化器按1个计算
+func formatterArgCount(p format.Placeholder) int {
+	if !p.HasFormatter {
+		return 1
+	}
+	if f, err := format.NewFormatter(p.Formatter); err == nil {
+		if m, ok := f.(format.IMultiArgFormatter); ok {
+			return m.ArgCount()
+		}
+	}
+	return 1
+}
+
+//formatterTypes 根据格式化器推断占位符使用的n个参数的类型，无法推断时为any
+//std格式化器的*宽度和精度参数为int，例如{:%*d}为[int int]
+func formatterTypes(p format.Placeholder, n int) []string {
+	types := make([]string, n)
+	for i := range types {
+		types[i] = "any"
+	}
+	if !p.HasFormatter || p.Formatter == "" {
+		return types
+	}
+	switch p.Label() {
+	case format.STD_FORMATTER_LABEL:
+		for i := 0; i < n-1; i++ {
+			types[i] = "int"
+		}
+		types[n-1] = verbType(p.Formatter[len(p.Formatter)-1])
+	case format.TIME_FORMATTER_LABEL:
+		types[0] = "time.Time"
+	case format.PASSWORD_FORMAT_LABEL:
+		types[0] = "string"
+	}
+	return types
+}
+
+//reservedNames 生成的代码中使用的名字，不能作为参数名
+var reservedNames = map[string]bool{"ctx": true, "context": true, "format": true, "time": true, "render": true}
+
+//reservedFuncs 生成的代码中导出的名字，不能作为访问函数名
+var reservedFuncs = map[string]bool{"Namespace": true, "NamespaceFor": true, "WithLocale": true}
+
+//paramName 将引用的键转换为参数名，取最后一段并转换为lowerCamel，例如user.first_name => firstName
+func paramName(key string) string {
+	if dot := strings.LastIndexByte(key, '.'); dot >= 0 {
+		key = key[dot+1:]
+	}
+	var sb strings.Builder
+	upper := false
+	for _, ch := range key {
+		if ch == '_' || ch == '-' {
+			upper = sb.Len() > 0
+			continue
+		}
+		if upper {
+			ch = unicode.ToUpper(ch)
+			upper = false
+		} else if sb.Len() == 0 {
+			ch = unicode.ToLower(ch)
+		}
+		sb.WriteRune(ch)
+	}
+	return sb.String()
+}
+
+//Params 根据消息（包括各个复数形式）中的占位符推断参数，同一个参数的类型不一致时为any
+//含复数形式的消息，第一个参数是数量，名字为count，类型为int；
+//作为唯一实参传给函数的$N以函数名命名，例如{{user_name($0)}}的参数为userName，其余参数为arg0、arg1…
+func Params(m *catalog.Message) ([]Param, error) {
+	types := make(map[int]string)
+	names := make(map[int]string)
+	setType := func(index int, typ string) {
+		if old, ok := types[index]; ok && old != typ {
+			typ = "any"
+		}
+		types[index] = typ
+	}
+	for _, text := range m.Texts() {
+		placeholders, err := format.Placeholders(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Key, err)
+		}
+		next := 0
+		for _, p := range placeholders {
+			if p.IsExpr {
+				indices, err := format.ParamIndices("{{" + p.Expr + "}}")
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", m.Key, err)
+				}
+				for _, param := range indices {
+					setType(param, "any")
+				}
+				refs, err := format.References("{{" + p.Expr + "}}")
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", m.Key, err)
+				}
+				for _, ref := range refs {
+					if !ref.IsFunc || ref.ArgCount != 1 || len(ref.Params) != 1 {
+						continue
+					}
+					if _, ok := names[ref.Params[0]]; !ok {
+						names[ref.Params[0]] = paramName(ref.Key)
+					}
+				}
+				continue
+			}
+			n := formatterArgCount(p)
+			var indices []int
+			switch {
+			case len(p.Indices) > 0:
+				indices = p.Indices
+			case p.Index >= 0:
+				for i := 0; i < n; i++ {
+					indices = append(indices, p.Index+i)
+				}
+			default:
+				for i := 0; i < n; i++ {
+					indices = append(indices, next+i)
+				}
+				next += n
+			}
+			types := formatterTypes(p, len(indices))
+			for i, index := range indices {
+				setType(index, types[i])
+			}
+		}
+	}
+	if len(m.Plurals) > 0 {
+		if typ, ok := types[0]; !ok || typ != "any" {
+			types[0] = "int"
+		}
+	}
+	count := 0
+	for index := range types {
+		if index+1 > count {
+			count = index + 1
+		}
+	}
+	if len(m.Plurals) > 0 {
+		names[0] = "count"
+	}
+	params := make([]Param, count)
+	used := make(map[string]bool)
+	for i := range params {
+		typ, ok := types[i]
+		if !ok {
+			typ = "any"
+		}
+		name := names[i]
+		if !token.IsIdentifier(name) || reservedNames[name] || used[name] {
+			name = "arg" + strconv.Itoa(i)
+		}
+		used[name] = true
+		params[i] = Param{Name: name, Type: typ}
+	}
+	return params, nil
+}
+
+//Funcs 为目录中的每条消息创建访问函数，函数名冲突时返回错误
+func Funcs(c *catalog.Catalog) ([]*Func, error) {
+	var funcs []*Func
+	names := make(map[string]string)
+	for _, m := range c.Messages() {
+		name := FuncName(m)
+		if reservedFuncs[name] {
+			return nil, fmt.Errorf("message %s maps to %s, which is reserved", m.ID(), name)
+		}
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("messages %s and %s both map to %s", other, m.ID(), name)
+		}
+		names[name] = m.ID()
+		params, err := Params(m)
+		if err != nil {
+			return nil, err
+		}
+		funcs = append(funcs, &Func{Name: name, Message: m, Params: params})
+	}
+	sort.Slice(funcs, func(i, j int) bool { return funcs[i].Name < funcs[j].Name })
+	return funcs, nil
+}
+
+//pattern 返回调用消息所用的表达式，例如{{Lang::menu.open($0, $1)}}
+func (f *Func) pattern(namespace string) string {
+	if namespace != "" {
+		return "{{" + namespace + "::" + f.call() + "}}"
+	}
+	return "{{" + f.call() + "}}"
+}
+
+//call 返回不带命名空间的调用，例如menu.open($0, $1)
+func (f *Func) call() string {
+	var sb strings.Builder
+	sb.WriteString(f.Message.Key)
+	if len(f.Params) > 0 {
+		sb.WriteString("(")
+		for i := range f.Params {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString("$" + strconv.Itoa(i))
+		}
+		sb.WriteString(")")
+	}
+	return sb.String()
+}
+
+//Generate 为翻译目录生成Go代码，每条消息对应一个函数，例如Greeting(ctx context.Context, name string) string，
+//通过format.Fmt调用解释器。解释器的命名空间由NamespaceFor(ctx)决定，默认为WithLocale设置的语言，没有时为Namespace，
+//与按语言注册解释器的方式配合使用，例如gettext.Register("zh_CN", catalog)
+func Generate(c *catalog.Catalog, cfg Config) ([]byte, error) {
+	if !token.IsIdentifier(cfg.Package) {
+		return nil, fmt.Errorf("invalid package name: %q", cfg.Package)
+	}
+	funcs, err := Funcs(c)
+	if err != nil {
+		return nil, err
+	}
+	useTime := false
+	for _, f := range funcs {
+		// 键只能包含表达式中允许的字符
+		refs, err := format.References(f.pattern(cfg.Namespace))
+		if err != nil || len(refs) != 1 || refs[0].Key != f.Message.Key {
+			return nil, fmt.Errorf("key %q can not be used in an expression", f.Message.Key)
+		}
+		for _, p := range f.Params {
+			useTime = useTime || p.Type == "time.Time"
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by khutils generate; DO NOT EDIT.\n")
+	if cfg.Source != "" {
+		fmt.Fprintf(&buf, "// Source: %s\n", cfg.Source)
+	}
+	fmt.Fprintf(&buf, "\npackage %s\n\nimport (\n\t\"context\"\n", cfg.Package)
+	if useTime {
+		buf.WriteString("\t\"time\"\n")
+	}
+	buf.WriteString("\n")
+	buf.WriteString("\t\"github.com/Khellendros97/khutils/format\"\n)\n")
+	fmt.Fprintf(&buf, "\n// Namespace is the interpreter namespace the messages are looked up in by default.\nconst Namespace = %q\n", cfg.Namespace)
+	buf.WriteString(runtimeSource)
+	for _, f := range funcs {
+		fmt.Fprintf(&buf, "\n// %s renders %s", f.Name, f.Message.Key)
+		if f.Message.Context != "" {
+			fmt.Fprintf(&buf, " (context %s)", f.Message.Context)
+		}
+		fmt.Fprintf(&buf, ":\n//\n//\t%s\n", strings.ReplaceAll(f.Message.Text, "\n", `\n`))
+		if f.Message.Comment != "" {
+			buf.WriteString("//\n")
+			for _, line := range strings.Split(f.Message.Comment, "\n") {
+				buf.WriteString("// " + line + "\n")
+			}
+		}
+		fmt.Fprintf(&buf, "func %s(ctx context.Context", f.Name)
+		args := make([]string, len(f.Params))
+		for i, p := range f.Params {
+			fmt.Fprintf(&buf, ", %s %s", p.Name, p.Type)
+			args[i] = ", " + p.Name
+		}
+		fmt.Fprintf(&buf, ") string {\n\treturn render(ctx, %q%s)\n}\n", f.call(), strings.Join(args, ""))
+	}
+	src, err := goformat.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+	return src, nil
+}
+
+//runtimeSource 生成的代码中选择命名空间和渲染消息的部分
+const runtimeSource = `
+type localeKey struct{}
+
+//WithLocale returns a copy of ctx whose messages are looked up in the
+//interpreter namespace registered for locale, e.g. by gettext.Register.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+//NamespaceFor returns the interpreter namespace messages are looked up in
+//for ctx: the locale set by WithLocale, or Namespace.
+var NamespaceFor = func(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok && locale != "" {
+		return locale
+	}
+	return Namespace
+}
+
+func render(ctx context.Context, call string, args ...any) string {
+	if namespace := NamespaceFor(ctx); namespace != "" {
+		call = namespace + "::" + call
+	}
+	return format.Fmt("{{"+call+"}}", args...)
+}
+`
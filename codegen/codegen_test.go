@@ -0,0 +1,51 @@
+package codegen
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Khellendros97/khutils/catalog"
+)
+
+func TestParamsMultiArg(t *testing.T) {
+	tests := []struct {
+		text string
+		want []Param
+	}{
+		{"{0,1:range} nights", []Param{{"arg0", "any"}, {"arg1", "any"}}},
+		{"[{:%*d}]", []Param{{"arg0", "int"}, {"arg1", "int"}}},
+		{"{:%.*f} {}", []Param{{"arg0", "int"}, {"arg1", "float64"}, {"arg2", "any"}}},
+		{"{1:ratio}", []Param{{"arg0", "any"}, {"arg1", "any"}, {"arg2", "any"}}},
+	}
+	for _, test := range tests {
+		got, err := Params(&catalog.Message{Key: "stay", Text: test.text})
+		if err != nil {
+			t.Errorf("Params(%q): %v", test.text, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Params(%q) = %v, want %v", test.text, got, test.want)
+		}
+	}
+}
+
+func TestGenerateMultiArg(t *testing.T) {
+	c := catalog.New("en")
+	c.Add(&catalog.Message{Key: "stay", Text: "{0,1:range} nights"})
+	c.Add(&catalog.Message{Key: "pad", Text: "[{:%*d}]"})
+	src, err := Generate(c, Config{Package: "msgs", Namespace: "Lang"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"func Stay(ctx context.Context, arg0 any, arg1 any) string",
+		`render(ctx, "stay($0, $1)", arg0, arg1)`,
+		"func Pad(ctx context.Context, arg0 int, arg1 int) string",
+		`render(ctx, "pad($0, $1)", arg0, arg1)`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated code does not contain %q:\n%s", want, src)
+		}
+	}
+}
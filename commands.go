@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+//commands 子命令，不带子命令运行时执行示例
+var commands = map[string]func(args []string) error{
+	"catalog":    runCatalog,
+	"extract":    runExtract,
+	"formatters": runFormatters,
+	"generate":   runGenerate,
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(os.Stderr, "usage: khutils <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, name := range names {
+		fmt.Fprintln(os.Stderr, "  "+name)
+	}
+}
+
+//runCommand 执行子命令，返回进程的退出码
+func runCommand(args []string) int {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "khutils: unknown command %q\n", args[0])
+		usage()
+		return 2
+	}
+	if err := cmd(args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "khutils %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
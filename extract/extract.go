@@ -0,0 +1,364 @@
+package extract
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Khellendros97/khutils/catalog"
+	"github.com/Khellendros97/khutils/format"
+)
+
+//DefaultFuncs 默认提取的函数，格式化字符串都是第一个参数
+var DefaultFuncs = []string{"format.Fmt"}
+
+//Func 需要提取格式化字符串的函数
+type Func struct {
+	Package  string // 包的导入路径或包名，为空时匹配同一个包内不带包名的调用
+	Name     string // 函数名
+	ArgIndex int    // 格式化字符串是第几个实参
+}
+
+//ParseFunc 解析函数描述：[包.]函数名[:实参下标]，例如format.Fmt、mylog.Infof:1、T
+//包可以写完整的导入路径，例如github.com/foo/i18n.T
+func ParseFunc(spec string) (Func, error) {
+	f := Func{}
+	if name, index, ok := strings.Cut(spec, ":"); ok {
+		n, err := strconv.Atoi(index)
+		if err != nil || n < 0 {
+			return f, fmt.Errorf("invalid argument index: %s", spec)
+		}
+		spec, f.ArgIndex = name, n
+	}
+	if dot := strings.LastIndexByte(spec, '.'); dot >= 0 {
+		f.Package, f.Name = spec[:dot], spec[dot+1:]
+	} else {
+		f.Name = spec
+	}
+	if f.Name == "" || !token.IsIdentifier(f.Name) {
+		return f, fmt.Errorf("invalid function: %s", spec)
+	}
+	return f, nil
+}
+
+//Message 提取到的一个表达式引用
+type Message struct {
+	Namespace string   // 命名空间，省略时为空
+	Key       string   // 变量名或函数名
+	IsFunc    bool     // 是否以函数的形式调用
+	ArgCount  int      // 函数调用的实参个数，各处不一致时取最大值
+	Locations []string // 源码位置，file:line
+}
+
+//Extractor 从Go源码中提取格式化字符串引用的表达式
+type Extractor struct {
+	Funcs  []Func
+	Errors []error // 解析格式化字符串时遇到的错误，不会中断提取
+
+	fset     *token.FileSet
+	messages map[string]*Message
+	order    []string
+}
+
+//NewExtractor 创建提取器，funcs为空时使用DefaultFuncs
+func NewExtractor(funcs ...string) (*Extractor, error) {
+	if len(funcs) == 0 {
+		funcs = DefaultFuncs
+	}
+	e := &Extractor{
+		fset:     token.NewFileSet(),
+		messages: make(map[string]*Message),
+	}
+	for _, spec := range funcs {
+		f, err := ParseFunc(spec)
+		if err != nil {
+			return nil, err
+		}
+		e.Funcs = append(e.Funcs, f)
+	}
+	return e, nil
+}
+
+//AddPath 提取文件或目录中的源码，目录以/...结尾时递归处理子目录
+//跳过_test.go文件以及vendor、testdata和以.或_开头的目录
+func (e *Extractor) AddPath(path string) error {
+	if dir, ok := strings.CutSuffix(path, "..."); ok {
+		dir = filepath.Clean(strings.TrimSuffix(dir, "/"))
+		if dir == "" {
+			dir = "."
+		}
+		return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			name := d.Name()
+			if p != dir && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return e.AddDir(p)
+		})
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return e.AddDir(path)
+	}
+	return e.AddFiles(path)
+}
+
+//AddDir 提取目录中（不含子目录）的所有Go源码
+func (e *Extractor) AddDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	return e.AddFiles(files...)
+}
+
+//AddFiles 提取同一个包中的源码文件，包内的字符串常量可以作为格式化字符串
+func (e *Extractor) AddFiles(filenames ...string) error {
+	var files []*ast.File
+	for _, filename := range filenames {
+		file, err := parser.ParseFile(e.fset, filename, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+	}
+	consts := packageConsts(files)
+	for _, file := range files {
+		imports := fileImports(file)
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			f, ok := e.match(call.Fun, imports)
+			if !ok || f.ArgIndex >= len(call.Args) {
+				return true
+			}
+			pattern, ok := constString(call.Args[f.ArgIndex], consts)
+			if ok {
+				e.addPattern(pattern, call.Args[f.ArgIndex].Pos())
+			}
+			return true
+		})
+	}
+	return nil
+}
+
+//match 判断被调用的函数是否需要提取
+func (e *Extractor) match(fun ast.Expr, imports map[string]string) (Func, bool) {
+	pkg, name := "", ""
+	switch fn := fun.(type) {
+	case *ast.Ident:
+		name = fn.Name
+	case *ast.SelectorExpr:
+		x, ok := fn.X.(*ast.Ident)
+		if !ok {
+			return Func{}, false
+		}
+		path, ok := imports[x.Name]
+		if !ok {
+			return Func{}, false
+		}
+		pkg, name = path, fn.Sel.Name
+	default:
+		return Func{}, false
+	}
+	for _, f := range e.Funcs {
+		if f.Name != name {
+			continue
+		}
+		if f.Package == "" && pkg == "" {
+			return f, true
+		}
+		if pkg != "" && (f.Package == pkg || f.Package == importName(pkg)) {
+			return f, true
+		}
+	}
+	return Func{}, false
+}
+
+func (e *Extractor) addPattern(pattern string, pos token.Pos) {
+	position := e.fset.Position(pos)
+	location := filepath.ToSlash(position.Filename) + ":" + strconv.Itoa(position.Line)
+	refs, err := format.References(pattern)
+	if err != nil {
+		e.Errors = append(e.Errors, fmt.Errorf("%s: %q: %w", position, pattern, err))
+	}
+	for _, ref := range refs {
+		id := catalog.ID(ref.Namespace, ref.Key)
+		m, ok := e.messages[id]
+		if !ok {
+			m = &Message{Namespace: ref.Namespace, Key: ref.Key}
+			e.messages[id] = m
+			e.order = append(e.order, id)
+		}
+		m.IsFunc = m.IsFunc || ref.IsFunc
+		if ref.ArgCount > m.ArgCount {
+			m.ArgCount = ref.ArgCount
+		}
+		if len(m.Locations) == 0 || m.Locations[len(m.Locations)-1] != location {
+			m.Locations = append(m.Locations, location)
+		}
+	}
+}
+
+//Messages 按首次出现的顺序返回提取到的引用
+func (e *Extractor) Messages() []*Message {
+	messages := make([]*Message, 0, len(e.order))
+	for _, id := range e.order {
+		messages = append(messages, e.messages[id])
+	}
+	return messages
+}
+
+//Catalog 生成翻译模板，命名空间作为消息的上下文，namespaces不为空时只包含这些命名空间
+//消息文本为空，函数的实参个数写在注释中
+func (e *Extractor) Catalog(locale string, namespaces ...string) *catalog.Catalog {
+	c := catalog.New(locale)
+	for _, m := range e.Messages() {
+		if len(namespaces) > 0 && !contains(namespaces, m.Namespace) {
+			continue
+		}
+		comment := "variable"
+		if m.IsFunc {
+			comment = fmt.Sprintf("function, %d args", m.ArgCount)
+		}
+		if m.Namespace != "" {
+			comment = "namespace " + m.Namespace + ", " + comment
+		}
+		c.Add(&catalog.Message{
+			Key:       m.Key,
+			Context:   m.Namespace,
+			Comment:   comment,
+			Locations: m.Locations,
+		})
+	}
+	return c
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+//importName 按导入路径推断包名：去掉/v2这样的主版本后缀和gopkg.in的.v3后缀，
+//以及go-前缀，例如gopkg.in/yaml.v3 => yaml，github.com/foo/bar/v2 => bar
+func importName(importPath string) string {
+	base := path.Base(importPath)
+	if strings.HasPrefix(base, "v") {
+		if _, err := strconv.Atoi(base[1:]); err == nil && path.Dir(importPath) != "." {
+			base = path.Base(path.Dir(importPath))
+		}
+	}
+	base = strings.TrimPrefix(base, "go-")
+	if i := strings.IndexFunc(base, func(ch rune) bool {
+		return !(ch == '_' || unicode.IsLetter(ch) || unicode.IsDigit(ch))
+	}); i >= 0 {
+		base = base[:i]
+	}
+	return base
+}
+
+//fileImports 返回文件中导入包的本地名称到导入路径的映射
+func fileImports(file *ast.File) map[string]string {
+	imports := make(map[string]string)
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := importName(path)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name == "_" || name == "." {
+			continue
+		}
+		imports[name] = path
+	}
+	return imports
+}
+
+//packageConsts 收集包级别的字符串常量声明
+func packageConsts(files []*ast.File) map[string]ast.Expr {
+	consts := make(map[string]ast.Expr)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, name := range vs.Names {
+					if i < len(vs.Values) {
+						consts[name.Name] = vs.Values[i]
+					}
+				}
+			}
+		}
+	}
+	return consts
+}
+
+//constString 计算常量字符串表达式的值，支持字面量、包级常量、括号和+拼接
+func constString(expr ast.Expr, consts map[string]ast.Expr) (string, bool) {
+	switch x := expr.(type) {
+	case *ast.BasicLit:
+		if x.Kind != token.STRING {
+			return "", false
+		}
+		s, err := strconv.Unquote(x.Value)
+		return s, err == nil
+	case *ast.ParenExpr:
+		return constString(x.X, consts)
+	case *ast.BinaryExpr:
+		if x.Op != token.ADD {
+			return "", false
+		}
+		left, ok := constString(x.X, consts)
+		if !ok {
+			return "", false
+		}
+		right, ok := constString(x.Y, consts)
+		return left + right, ok
+	case *ast.Ident:
+		value, ok := consts[x.Name]
+		if !ok {
+			return "", false
+		}
+		// 避免常量之间循环引用
+		delete(consts, x.Name)
+		defer func() { consts[x.Name] = value }()
+		return constString(value, consts)
+	}
+	return "", false
+}
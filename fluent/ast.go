@@ -0,0 +1,71 @@
+package fluent
+
+//Resource 一个.ftl文件解析后的结果
+type Resource struct {
+	Messages []*Message
+	Terms    []*Message
+}
+
+//Message 消息或术语（术语的ID以-开头）
+type Message struct {
+	ID         string
+	Value      Pattern // 没有值（只有属性）时为nil
+	Attributes map[string]Pattern
+	Comment    string
+}
+
+//Pattern 由文本和占位符组成的模式
+type Pattern []PatternElement
+
+type PatternElement interface{}
+
+type textElement string
+
+type placeable struct {
+	expr expression
+}
+
+type expression interface{}
+
+type stringLiteral string
+
+type numberLiteral string
+
+type variableRef struct {
+	name string
+}
+
+type messageRef struct {
+	id   string
+	attr string
+}
+
+type termRef struct {
+	id   string
+	attr string
+	args *callArgs
+}
+
+type functionRef struct {
+	name string
+	args *callArgs
+}
+
+type callArgs struct {
+	positional []expression
+	named      map[string]expression
+}
+
+type variant struct {
+	key       expression // stringLiteral（标识符）或numberLiteral
+	value     Pattern
+	isDefault bool
+}
+
+type selectExpr struct {
+	selector expression
+	variants []*variant
+}
+
+//indentElement 续行开头的缩进，解析完成后去除公共缩进并转换为文本
+type indentElement int
@@ -0,0 +1,377 @@
+package fluent
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Khellendros97/khutils/plural"
+)
+
+//maxDepth 消息相互引用的最大深度，用于防止循环引用
+const maxDepth = 100
+
+//Value Fluent表达式的值
+type Value interface {
+	String() string
+}
+
+//StringValue 字符串值
+type StringValue string
+
+func (v StringValue) String() string {
+	return string(v)
+}
+
+//NumberValue 数值，Text是格式化后的文本，同时用于复数类别的判断（例如"1.00"在英语中不属于one）
+type NumberValue struct {
+	Value float64
+	Text  string
+}
+
+func (v NumberValue) String() string {
+	return v.Text
+}
+
+//AnyValue 其他Go值，例如time.Time
+type AnyValue struct {
+	Value any
+}
+
+func (v AnyValue) String() string {
+	if t, ok := v.Value.(time.Time); ok {
+		return formatDateTime(t, "datetime")
+	}
+	return fmt.Sprintf("%v", v.Value)
+}
+
+//noneValue 求值失败时的占位值
+type noneValue string
+
+func (v noneValue) String() string {
+	return "{" + string(v) + "}"
+}
+
+//Function 可以在消息中调用的函数，例如NUMBER($n, minimumFractionDigits: 2)
+type Function func(positional []Value, named map[string]Value) (Value, error)
+
+//Bundle 一个语言的Fluent消息集合
+type Bundle struct {
+	Locale    string
+	Functions map[string]Function
+
+	messages map[string]*Message
+	terms    map[string]*Message
+}
+
+func NewBundle(locale string) *Bundle {
+	return &Bundle{
+		Locale: locale,
+		Functions: map[string]Function{
+			"NUMBER":   Number,
+			"DATETIME": DateTime,
+		},
+		messages: make(map[string]*Message),
+		terms:    make(map[string]*Message),
+	}
+}
+
+//AddResource 添加解析后的资源，已存在的消息会被覆盖
+func (b *Bundle) AddResource(res *Resource) {
+	for _, msg := range res.Messages {
+		b.messages[msg.ID] = msg
+	}
+	for _, term := range res.Terms {
+		b.terms[term.ID] = term
+	}
+}
+
+//AddFTL 解析并添加.ftl格式的内容
+func (b *Bundle) AddFTL(src string) error {
+	res, err := Parse(src)
+	if err != nil {
+		return err
+	}
+	b.AddResource(res)
+	return nil
+}
+
+//LoadFile 加载.ftl文件
+func (b *Bundle) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := b.AddFTL(string(data)); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+//Message 返回消息，不存在时返回nil
+func (b *Bundle) Message(id string) *Message {
+	return b.messages[id]
+}
+
+//MessageIDs 返回所有消息的ID
+func (b *Bundle) MessageIDs() []string {
+	ids := make([]string, 0, len(b.messages))
+	for id := range b.messages {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (b *Bundle) pattern(id string, attr string) (Pattern, error) {
+	msg := b.messages[id]
+	if msg == nil {
+		return nil, fmt.Errorf("unknown message: %s", id)
+	}
+	if attr == "" {
+		if msg.Value == nil {
+			return nil, fmt.Errorf("message %s has no value", id)
+		}
+		return msg.Value, nil
+	}
+	pattern, ok := msg.Attributes[attr]
+	if !ok {
+		return nil, fmt.Errorf("unknown attribute: %s.%s", id, attr)
+	}
+	return pattern, nil
+}
+
+//FormatMessage 格式化消息或其属性（attr不为空时）
+//@return str 格式化结果，出错的占位符会被替换为{$name}等形式; errs 求值过程中的错误
+func (b *Bundle) FormatMessage(id string, attr string, args map[string]any) (str string, errs []error) {
+	pattern, err := b.pattern(id, attr)
+	if err != nil {
+		return "", []error{err}
+	}
+	s := &scope{bundle: b, args: args}
+	str = s.resolvePattern(pattern)
+	return str, s.errors
+}
+
+//Variables 按首次出现的顺序返回消息（包括引用的其他消息）中使用的变量名
+func (b *Bundle) Variables(id string, attr string) []string {
+	pattern, err := b.pattern(id, attr)
+	if err != nil {
+		return nil
+	}
+	c := &varCollector{bundle: b, seen: make(map[string]bool), visited: make(map[string]bool)}
+	c.pattern(pattern)
+	return c.names
+}
+
+type varCollector struct {
+	bundle  *Bundle
+	names   []string
+	seen    map[string]bool
+	visited map[string]bool
+}
+
+func (c *varCollector) pattern(pattern Pattern) {
+	for _, el := range pattern {
+		if p, ok := el.(*placeable); ok {
+			c.expr(p.expr)
+		}
+	}
+}
+
+func (c *varCollector) expr(expr expression) {
+	switch e := expr.(type) {
+	case *variableRef:
+		if !c.seen[e.name] {
+			c.seen[e.name] = true
+			c.names = append(c.names, e.name)
+		}
+	case *messageRef:
+		key := e.id + "." + e.attr
+		if c.visited[key] {
+			return
+		}
+		c.visited[key] = true
+		if pattern, err := c.bundle.pattern(e.id, e.attr); err == nil {
+			c.pattern(pattern)
+		}
+	case *functionRef:
+		for _, arg := range e.args.positional {
+			c.expr(arg)
+		}
+	case *selectExpr:
+		c.expr(e.selector)
+		for _, v := range e.variants {
+			c.pattern(v.value)
+		}
+	}
+}
+
+//scope 一次格式化的求值环境
+type scope struct {
+	bundle *Bundle
+	args   map[string]any
+	errors []error
+	depth  int
+}
+
+func (s *scope) errorf(format string, args ...any) {
+	s.errors = append(s.errors, fmt.Errorf(format, args...))
+}
+
+func (s *scope) resolvePattern(pattern Pattern) string {
+	s.depth++
+	defer func() { s.depth-- }()
+	if s.depth > maxDepth {
+		s.errorf("cyclic reference")
+		return "{???}"
+	}
+	var sb strings.Builder
+	for _, el := range pattern {
+		switch v := el.(type) {
+		case textElement:
+			sb.WriteString(string(v))
+		case *placeable:
+			sb.WriteString(s.resolve(v.expr).String())
+		}
+	}
+	return sb.String()
+}
+
+func (s *scope) resolve(expr expression) Value {
+	switch e := expr.(type) {
+	case stringLiteral:
+		return StringValue(e)
+	case numberLiteral:
+		n, _ := strconv.ParseFloat(string(e), 64)
+		return NumberValue{Value: n, Text: string(e)}
+	case *variableRef:
+		arg, ok := s.args[e.name]
+		if !ok {
+			s.errorf("unknown variable: $%s", e.name)
+			return noneValue("$" + e.name)
+		}
+		return ToValue(arg)
+	case *messageRef:
+		pattern, err := s.bundle.pattern(e.id, e.attr)
+		if err != nil {
+			s.errors = append(s.errors, err)
+			return noneValue(refName(e.id, e.attr))
+		}
+		return StringValue(s.resolvePattern(pattern))
+	case *termRef:
+		return s.resolveTerm(e)
+	case *functionRef:
+		return s.resolveFunction(e)
+	case *selectExpr:
+		return StringValue(s.resolvePattern(s.selectVariant(e).value))
+	}
+	s.errorf("unknown expression: %v", expr)
+	return noneValue("???")
+}
+
+func refName(id string, attr string) string {
+	if attr == "" {
+		return id
+	}
+	return id + "." + attr
+}
+
+func (s *scope) resolveTerm(e *termRef) Value {
+	term := s.bundle.terms[e.id]
+	if term == nil {
+		s.errorf("unknown term: %s", e.id)
+		return noneValue(refName(e.id, e.attr))
+	}
+	pattern := term.Value
+	if e.attr != "" {
+		var ok bool
+		if pattern, ok = term.Attributes[e.attr]; !ok {
+			s.errorf("unknown attribute: %s.%s", e.id, e.attr)
+			return noneValue(refName(e.id, e.attr))
+		}
+	}
+	// 术语只能看到调用时传入的命名参数
+	args := make(map[string]any)
+	if e.args != nil {
+		for name, arg := range e.args.named {
+			args[name] = s.resolve(arg)
+		}
+	}
+	inner := &scope{bundle: s.bundle, args: args, depth: s.depth}
+	str := inner.resolvePattern(pattern)
+	s.errors = append(s.errors, inner.errors...)
+	return StringValue(str)
+}
+
+func (s *scope) resolveFunction(e *functionRef) Value {
+	fn, ok := s.bundle.Functions[e.name]
+	if !ok {
+		s.errorf("unknown function: %s", e.name)
+		return noneValue(e.name + "()")
+	}
+	positional := make([]Value, len(e.args.positional))
+	for i, arg := range e.args.positional {
+		positional[i] = s.resolve(arg)
+	}
+	named := make(map[string]Value, len(e.args.named))
+	for name, arg := range e.args.named {
+		named[name] = s.resolve(arg)
+	}
+	value, err := fn(positional, named)
+	if err != nil {
+		s.errorf("%s: %w", e.name, err)
+		return noneValue(e.name + "()")
+	}
+	return value
+}
+
+func (s *scope) selectVariant(e *selectExpr) *variant {
+	selector := s.resolve(e.selector)
+	var category plural.Category
+	number, isNumber := selector.(NumberValue)
+	if isNumber {
+		category = plural.Cardinal(s.bundle.Locale, number.Text)
+	}
+	var def *variant
+	for _, v := range e.variants {
+		if v.isDefault {
+			def = v
+		}
+		switch key := v.key.(type) {
+		case numberLiteral:
+			if n, err := strconv.ParseFloat(string(key), 64); err == nil && isNumber && n == number.Value {
+				return v
+			}
+		case stringLiteral:
+			if isNumber && string(key) == string(category) {
+				return v
+			}
+			if !isNumber && string(key) == selector.String() {
+				return v
+			}
+		}
+	}
+	return def
+}
+
+//ToValue 将Go值转换为Fluent值，数值类型转换为NumberValue以便用于复数选择
+func ToValue(arg any) Value {
+	switch v := arg.(type) {
+	case time.Time:
+		return AnyValue{Value: v}
+	case Value:
+		return v
+	case string:
+		return StringValue(v)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		n, _ := strconv.ParseFloat(fmt.Sprintf("%d", v), 64)
+		return NumberValue{Value: n, Text: fmt.Sprintf("%d", v)}
+	case float32:
+		return NumberValue{Value: float64(v), Text: strconv.FormatFloat(float64(v), 'f', -1, 32)}
+	case float64:
+		return NumberValue{Value: v, Text: strconv.FormatFloat(v, 'f', -1, 64)}
+	}
+	return AnyValue{Value: arg}
+}
@@ -0,0 +1,116 @@
+package fluent
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Khellendros97/khutils/format"
+)
+
+func optionInt(named map[string]Value, name string) (int, bool, error) {
+	v, ok := named[name]
+	if !ok {
+		return 0, false, nil
+	}
+	n, err := strconv.Atoi(v.String())
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid %s: %s", name, v)
+	}
+	return n, true, nil
+}
+
+//Number 内置的NUMBER函数，使用std格式化器格式化数值
+//支持的命名参数：minimumFractionDigits、maximumFractionDigits、style（decimal或percent）
+func Number(positional []Value, named map[string]Value) (Value, error) {
+	if len(positional) != 1 {
+		return nil, fmt.Errorf("expected 1 argument, got %d", len(positional))
+	}
+	var n float64
+	switch v := positional[0].(type) {
+	case NumberValue:
+		n = v.Value
+	default:
+		var err error
+		if n, err = strconv.ParseFloat(strings.TrimSpace(v.String()), 64); err != nil {
+			return nil, fmt.Errorf("not a number: %s", v)
+		}
+	}
+	suffix := ""
+	if style, ok := named["style"]; ok && style.String() == "percent" {
+		n *= 100
+		suffix = "%"
+	}
+	minDigits, hasMin, err := optionInt(named, "minimumFractionDigits")
+	if err != nil {
+		return nil, err
+	}
+	maxDigits, hasMax, err := optionInt(named, "maximumFractionDigits")
+	if err != nil {
+		return nil, err
+	}
+	if !hasMax {
+		maxDigits = 3
+		if suffix != "" {
+			maxDigits = 0
+		}
+	}
+	if maxDigits < minDigits {
+		maxDigits = minDigits
+	}
+	std := format.NewStdFormatter()
+	if err := std.Parse(fmt.Sprintf(".%df", maxDigits)); err != nil {
+		return nil, err
+	}
+	text := std.Format(n)
+	// 去掉超出最少位数的末尾的0
+	if intPart, frac, ok := strings.Cut(text, "."); ok {
+		frac = strings.TrimRight(frac, "0")
+		if hasMin || len(frac) < minDigits {
+			for len(frac) < minDigits {
+				frac += "0"
+			}
+		}
+		text = intPart
+		if frac != "" {
+			text += "." + frac
+		}
+	}
+	return NumberValue{Value: n, Text: text + suffix}, nil
+}
+
+//DateTime 内置的DATETIME函数，使用时间格式化器格式化时间
+//支持的命名参数：format（与{:@...}的写法相同）、dateStyle、timeStyle
+func DateTime(positional []Value, named map[string]Value) (Value, error) {
+	if len(positional) != 1 {
+		return nil, fmt.Errorf("expected 1 argument, got %d", len(positional))
+	}
+	token := "datetime"
+	_, hasDate := named["dateStyle"]
+	_, hasTime := named["timeStyle"]
+	switch {
+	case hasDate && !hasTime:
+		token = "date"
+	case hasTime && !hasDate:
+		token = "time"
+	}
+	if f, ok := named["format"]; ok {
+		token = f.String()
+	}
+	var value any
+	switch v := positional[0].(type) {
+	case AnyValue:
+		value = v.Value
+	case NumberValue:
+		value = int64(v.Value)
+	default:
+		value = v.String()
+	}
+	return StringValue(formatDateTime(value, token)), nil
+}
+
+func formatDateTime(value any, token string) string {
+	f := format.NewTimeFormatter()
+	f.Parse(token)
+	return f.Format(value)
+}
@@ -0,0 +1,72 @@
+package fluent
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Khellendros97/khutils/format"
+)
+
+//Interpreter 基于Fluent消息的表达式解释器
+//{{ftl::emails($0)}}格式化消息emails，{{ftl::login.placeholder}}格式化消息login的placeholder属性。
+//表达式的标签不能包含-，所以ID中的-可以写成_，例如{{ftl::hello_user}}在没有hello_user时查找hello-user。
+//类型为map[string]any的实参按名字绑定变量，其余实参按变量在消息中首次出现的顺序绑定
+type Interpreter struct {
+	Bundle *Bundle
+}
+
+func NewInterpreter(bundle *Bundle) *Interpreter {
+	return &Interpreter{Bundle: bundle}
+}
+
+//Register 将消息集合作为表达式解释器注册到命名空间，命名空间为tpl时返回错误
+func Register(namespace string, bundle *Bundle) (*Interpreter, error) {
+	interpreter := NewInterpreter(bundle)
+	if err := format.RegisterInterpreter(namespace, interpreter); err != nil {
+		return nil, err
+	}
+	return interpreter, nil
+}
+
+//Locale 返回消息集合的语言
+func (i *Interpreter) Locale() string {
+	return i.Bundle.Locale
+}
+
+func (i *Interpreter) Format(key string, args []any) (string, error) {
+	id, attr, _ := strings.Cut(key, ".")
+	if i.Bundle.Message(id) == nil {
+		id = strings.ReplaceAll(id, "_", "-")
+	}
+	// 消息不存在、属性不存在或者消息没有值
+	if _, err := i.Bundle.pattern(id, attr); err != nil {
+		return "", fmt.Errorf("%w: %s", format.ErrKeyNotFound, key)
+	}
+	named := make(map[string]any)
+	var positional []any
+	for _, arg := range args {
+		if m, ok := arg.(map[string]any); ok {
+			for k, v := range m {
+				named[k] = v
+			}
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) > 0 {
+		for index, name := range i.Bundle.Variables(id, attr) {
+			if index >= len(positional) {
+				break
+			}
+			if _, ok := named[name]; !ok {
+				named[name] = positional[index]
+			}
+		}
+	}
+	str, errs := i.Bundle.FormatMessage(id, attr, named)
+	if len(errs) > 0 {
+		return "", fmt.Errorf("%s: %w", key, errors.Join(errs...))
+	}
+	return str, nil
+}
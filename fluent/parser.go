@@ -0,0 +1,595 @@
+package fluent
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//parser Fluent语法（Fluent Syntax 1.0）的递归下降解析器
+type parser struct {
+	src string
+	pos int
+}
+
+//Parse 解析.ftl文件的内容
+func Parse(src string) (*Resource, error) {
+	p := &parser{src: strings.ReplaceAll(src, "\r\n", "\n")}
+	return p.parseResource()
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	line := strings.Count(p.src[:p.pos], "\n") + 1
+	col := p.pos - strings.LastIndexByte(p.src[:p.pos], '\n')
+	return fmt.Errorf("ftl %d:%d: %s", line, col, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) expect(ch byte) error {
+	if p.peek() != ch {
+		if p.eof() {
+			return p.errorf("expected '%c', got end of file", ch)
+		}
+		return p.errorf("expected '%c', got '%c'", ch, p.peek())
+	}
+	p.pos++
+	return nil
+}
+
+//skipBlankInline 跳过行内空格
+func (p *parser) skipBlankInline() {
+	for p.peek() == ' ' {
+		p.pos++
+	}
+}
+
+//skipBlank 跳过空格和换行
+func (p *parser) skipBlank() {
+	for ch := p.peek(); ch == ' ' || ch == '\n'; ch = p.peek() {
+		p.pos++
+	}
+}
+
+//skipBlankLines 跳过只包含空格的行，停在下一个非空行的行首
+func (p *parser) skipBlankLines() {
+	for !p.eof() {
+		end := strings.IndexByte(p.src[p.pos:], '\n')
+		line := p.src[p.pos:]
+		if end >= 0 {
+			line = line[:end]
+		}
+		if strings.TrimLeft(line, " ") != "" {
+			return
+		}
+		if end < 0 {
+			p.pos = len(p.src)
+			return
+		}
+		p.pos += end + 1
+	}
+}
+
+func (p *parser) readLine() string {
+	end := strings.IndexByte(p.src[p.pos:], '\n')
+	if end < 0 {
+		line := p.src[p.pos:]
+		p.pos = len(p.src)
+		return line
+	}
+	line := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	return line
+}
+
+func isIdentStart(ch byte) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isIdentChar(ch byte) bool {
+	return isIdentStart(ch) || (ch >= '0' && ch <= '9') || ch == '_' || ch == '-'
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func (p *parser) parseIdentifier() (string, error) {
+	start := p.pos
+	if !isIdentStart(p.peek()) {
+		return "", p.errorf("expected identifier")
+	}
+	for isIdentChar(p.peek()) {
+		p.pos++
+	}
+	return p.src[start:p.pos], nil
+}
+
+func (p *parser) parseResource() (*Resource, error) {
+	res := &Resource{}
+	var comment []string
+	for {
+		p.skipBlankLines()
+		if p.eof() {
+			return res, nil
+		}
+		ch := p.peek()
+		switch {
+		case ch == '#':
+			line := p.readLine()
+			if strings.HasPrefix(line, "##") { // 组注释和资源注释不属于任何消息
+				comment = nil
+				continue
+			}
+			comment = append(comment, strings.TrimPrefix(strings.TrimPrefix(line, "#"), " "))
+			// 注释与消息之间有空行时，注释不属于该消息
+			if strings.HasPrefix(p.src[p.pos:], "\n") {
+				comment = nil
+			}
+		case ch == '-' || isIdentStart(ch):
+			msg, err := p.parseEntry()
+			if err != nil {
+				return nil, err
+			}
+			msg.Comment = strings.Join(comment, "\n")
+			comment = nil
+			if strings.HasPrefix(msg.ID, "-") {
+				res.Terms = append(res.Terms, msg)
+			} else {
+				res.Messages = append(res.Messages, msg)
+			}
+		default:
+			return nil, p.errorf("expected message, term or comment")
+		}
+	}
+}
+
+func (p *parser) parseEntry() (*Message, error) {
+	isTerm := p.peek() == '-'
+	if isTerm {
+		p.pos++
+	}
+	id, err := p.parseIdentifier()
+	if err != nil {
+		return nil, err
+	}
+	if isTerm {
+		id = "-" + id
+	}
+	p.skipBlankInline()
+	if err := p.expect('='); err != nil {
+		return nil, err
+	}
+	value, err := p.parsePattern()
+	if err != nil {
+		return nil, err
+	}
+	msg := &Message{ID: id, Value: value, Attributes: make(map[string]Pattern)}
+	for {
+		start := p.pos
+		p.skipBlank()
+		if p.peek() != '.' || p.pos == start || p.src[p.pos-1] != ' ' {
+			p.pos = start
+			break
+		}
+		p.pos++
+		name, err := p.parseIdentifier()
+		if err != nil {
+			return nil, err
+		}
+		p.skipBlankInline()
+		if err := p.expect('='); err != nil {
+			return nil, err
+		}
+		attr, err := p.parsePattern()
+		if err != nil {
+			return nil, err
+		}
+		if attr == nil {
+			return nil, p.errorf("attribute %s.%s has no value", id, name)
+		}
+		msg.Attributes[name] = attr
+	}
+	if msg.Value == nil && (isTerm || len(msg.Attributes) == 0) {
+		return nil, p.errorf("%s has no value", id)
+	}
+	return msg, nil
+}
+
+//continuation 判断当前换行之后是否是模式的续行
+//@return next 续行（缩进之后）的位置; newlines 跨过的换行数量; indent 缩进宽度
+func (p *parser) continuation() (next int, newlines int, indent int, ok bool) {
+	i := p.pos
+	for i < len(p.src) {
+		if p.src[i] != '\n' {
+			return
+		}
+		newlines++
+		i++
+		indent = 0
+		for i < len(p.src) && p.src[i] == ' ' {
+			indent++
+			i++
+		}
+		if i >= len(p.src) {
+			return
+		}
+		if p.src[i] == '\n' { // 空行
+			continue
+		}
+		if indent == 0 {
+			return
+		}
+		switch p.src[i] {
+		case '[', '*', '.', '}':
+			return
+		}
+		return i, newlines, indent, true
+	}
+	return
+}
+
+func (p *parser) parsePattern() (Pattern, error) {
+	var elements Pattern
+	p.skipBlankInline()
+	for !p.eof() {
+		switch p.peek() {
+		case '{':
+			expr, err := p.parsePlaceable()
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, &placeable{expr: expr})
+		case '}':
+			return nil, p.errorf("unbalanced closing brace")
+		case '\n':
+			next, newlines, indent, ok := p.continuation()
+			if !ok {
+				return dedent(elements), nil
+			}
+			elements = append(elements, textElement(strings.Repeat("\n", newlines)), indentElement(indent))
+			p.pos = next
+		default:
+			start := p.pos
+			for ch := p.peek(); !p.eof() && ch != '{' && ch != '}' && ch != '\n'; ch = p.peek() {
+				p.pos++
+			}
+			elements = append(elements, textElement(p.src[start:p.pos]))
+		}
+	}
+	return dedent(elements), nil
+}
+
+//dedent 去除续行的公共缩进、开头的换行和末尾的空白，并合并相邻的文本
+func dedent(elements Pattern) Pattern {
+	common := -1
+	for _, el := range elements {
+		if indent, ok := el.(indentElement); ok && (common < 0 || int(indent) < common) {
+			common = int(indent)
+		}
+	}
+	var result Pattern
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			result = append(result, textElement(text.String()))
+			text.Reset()
+		}
+	}
+	for _, el := range elements {
+		switch v := el.(type) {
+		case textElement:
+			if len(result) == 0 && text.Len() == 0 {
+				v = textElement(strings.TrimLeft(string(v), "\n"))
+			}
+			text.WriteString(string(v))
+		case indentElement:
+			if len(result) > 0 || text.Len() > 0 {
+				text.WriteString(strings.Repeat(" ", int(v)-common))
+			}
+		default:
+			flush()
+			result = append(result, el)
+		}
+	}
+	flush()
+	if len(result) > 0 {
+		if last, ok := result[len(result)-1].(textElement); ok {
+			trimmed := strings.TrimRight(string(last), " \n")
+			if trimmed == "" {
+				result = result[:len(result)-1]
+			} else {
+				result[len(result)-1] = textElement(trimmed)
+			}
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+func (p *parser) parsePlaceable() (expression, error) {
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	expr, err := p.parseInlineExpression()
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	if strings.HasPrefix(p.src[p.pos:], "->") {
+		switch v := expr.(type) {
+		case *messageRef:
+			return nil, p.errorf("message references can't be used as selectors")
+		case *termRef:
+			if v.attr == "" {
+				return nil, p.errorf("terms can't be used as selectors")
+			}
+		}
+		p.pos += 2
+		variants, err := p.parseVariants()
+		if err != nil {
+			return nil, err
+		}
+		expr = &selectExpr{selector: expr, variants: variants}
+		p.skipBlank()
+	}
+	if err := p.expect('}'); err != nil {
+		return nil, err
+	}
+	return expr, nil
+}
+
+func (p *parser) parseVariants() ([]*variant, error) {
+	var variants []*variant
+	hasDefault := false
+	for {
+		p.skipBlank()
+		v := &variant{}
+		start := p.pos
+		if p.peek() == '*' {
+			v.isDefault = true
+			p.pos++
+		}
+		if p.peek() != '[' {
+			p.pos = start
+			break
+		}
+		p.pos++
+		p.skipBlank()
+		if isDigit(p.peek()) || p.peek() == '-' {
+			num, err := p.parseNumber()
+			if err != nil {
+				return nil, err
+			}
+			v.key = num
+		} else {
+			id, err := p.parseIdentifier()
+			if err != nil {
+				return nil, err
+			}
+			v.key = stringLiteral(id)
+		}
+		p.skipBlank()
+		if err := p.expect(']'); err != nil {
+			return nil, err
+		}
+		value, err := p.parsePattern()
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			return nil, p.errorf("variant has no value")
+		}
+		v.value = value
+		if v.isDefault {
+			if hasDefault {
+				return nil, p.errorf("select expression has more than one default variant")
+			}
+			hasDefault = true
+		}
+		variants = append(variants, v)
+	}
+	if len(variants) == 0 {
+		return nil, p.errorf("select expression has no variants")
+	}
+	if !hasDefault {
+		return nil, p.errorf("select expression has no default variant")
+	}
+	return variants, nil
+}
+
+func (p *parser) parseInlineExpression() (expression, error) {
+	ch := p.peek()
+	switch {
+	case ch == '"':
+		return p.parseString()
+	case isDigit(ch) || (ch == '-' && p.pos+1 < len(p.src) && isDigit(p.src[p.pos+1])):
+		return p.parseNumber()
+	case ch == '-':
+		p.pos++
+		id, err := p.parseIdentifier()
+		if err != nil {
+			return nil, err
+		}
+		ref := &termRef{id: "-" + id}
+		if ref.attr, err = p.parseAttrAccessor(); err != nil {
+			return nil, err
+		}
+		if p.peek() == '(' {
+			if ref.args, err = p.parseCallArgs(); err != nil {
+				return nil, err
+			}
+		}
+		return ref, nil
+	case ch == '$':
+		p.pos++
+		id, err := p.parseIdentifier()
+		if err != nil {
+			return nil, err
+		}
+		return &variableRef{name: id}, nil
+	case ch == '{':
+		return p.parsePlaceable()
+	case isIdentStart(ch):
+		id, err := p.parseIdentifier()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() == '(' {
+			if strings.ToUpper(id) != id {
+				return nil, p.errorf("function names must be upper case: %s", id)
+			}
+			args, err := p.parseCallArgs()
+			if err != nil {
+				return nil, err
+			}
+			return &functionRef{name: id, args: args}, nil
+		}
+		ref := &messageRef{id: id}
+		if ref.attr, err = p.parseAttrAccessor(); err != nil {
+			return nil, err
+		}
+		return ref, nil
+	case ch == 0:
+		return nil, p.errorf("unexpected end of file")
+	}
+	return nil, p.errorf("unexpected '%c' in placeable", ch)
+}
+
+func (p *parser) parseAttrAccessor() (string, error) {
+	if p.peek() != '.' {
+		return "", nil
+	}
+	p.pos++
+	return p.parseIdentifier()
+}
+
+func (p *parser) parseCallArgs() (*callArgs, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	args := &callArgs{named: make(map[string]expression)}
+	for {
+		p.skipBlank()
+		if p.peek() == ')' {
+			p.pos++
+			return args, nil
+		}
+		expr, err := p.parseInlineExpression()
+		if err != nil {
+			return nil, err
+		}
+		p.skipBlank()
+		if ref, ok := expr.(*messageRef); ok && ref.attr == "" && p.peek() == ':' {
+			p.pos++
+			p.skipBlank()
+			var value expression
+			switch {
+			case p.peek() == '"':
+				value, err = p.parseString()
+			case isDigit(p.peek()) || p.peek() == '-':
+				value, err = p.parseNumber()
+			default:
+				err = p.errorf("named argument %s must be a literal", ref.id)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := args.named[ref.id]; ok {
+				return nil, p.errorf("duplicate named argument: %s", ref.id)
+			}
+			args.named[ref.id] = value
+		} else {
+			if len(args.named) > 0 {
+				return nil, p.errorf("positional arguments must come before named arguments")
+			}
+			args.positional = append(args.positional, expr)
+		}
+		p.skipBlank()
+		if p.peek() == ',' {
+			p.pos++
+			continue
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf("expected ',' or ')' in argument list")
+		}
+	}
+}
+
+func (p *parser) parseNumber() (numberLiteral, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	if !isDigit(p.peek()) {
+		return "", p.errorf("expected number")
+	}
+	for isDigit(p.peek()) {
+		p.pos++
+	}
+	if p.peek() == '.' {
+		p.pos++
+		if !isDigit(p.peek()) {
+			return "", p.errorf("expected digits after '.'")
+		}
+		for isDigit(p.peek()) {
+			p.pos++
+		}
+	}
+	return numberLiteral(p.src[start:p.pos]), nil
+}
+
+func (p *parser) parseString() (stringLiteral, error) {
+	if err := p.expect('"'); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string literal")
+		}
+		ch := p.peek()
+		p.pos++
+		switch ch {
+		case '"':
+			return stringLiteral(sb.String()), nil
+		case '\\':
+			esc := p.peek()
+			p.pos++
+			switch esc {
+			case '\\', '"':
+				sb.WriteByte(esc)
+			case 'u', 'U':
+				size := 4
+				if esc == 'U' {
+					size = 6
+				}
+				if p.pos+size > len(p.src) {
+					return "", p.errorf("invalid unicode escape")
+				}
+				code, err := strconv.ParseUint(p.src[p.pos:p.pos+size], 16, 32)
+				if err != nil {
+					return "", p.errorf("invalid unicode escape: %s", p.src[p.pos:p.pos+size])
+				}
+				sb.WriteRune(rune(code))
+				p.pos += size
+			default:
+				return "", p.errorf("unknown escape sequence: \\%c", esc)
+			}
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+}
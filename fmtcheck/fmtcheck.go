@@ -0,0 +1,328 @@
+package fmtcheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"path"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+
+	"github.com/Khellendros97/khutils/extract"
+	"github.com/Khellendros97/khutils/format"
+)
+
+const doc = `check format.Fmt calls
+
+The fmtcheck analyzer reports constant format.Fmt patterns with syntax errors,
+placeholder or $N indices out of range, indexed placeholders mixed with
+non-indexed ones, unknown formatter labels or names, invalid arguments to
+named formatters and arguments whose type does not suit the formatter.
+Wrapper functions are checked with -funcs, formatters registered outside the
+checked package are declared with -labels and -names, custom expression
+operators with -operators.`
+
+//Analyzer 检查format.Fmt及其包装函数的调用，可以通过go vet -vettool运行
+var Analyzer = &analysis.Analyzer{
+	Name:     "fmtcheck",
+	Doc:      doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+var (
+	funcsFlag  string // 额外检查的包装函数，格式与extract相同：pkg.Func[:argIndex]
+	labelsFlag string // 在其他地方注册的格式化器标签
+	namesFlag  string // 在其他地方注册的命名格式化器
+	opsFlag    string // 运行时注册的操作符，只用于解析表达式
+)
+
+func init() {
+	Analyzer.Flags.StringVar(&funcsFlag, "funcs", "", "comma-separated wrapper functions taking a pattern, as pkg.Func[:argIndex]")
+	Analyzer.Flags.StringVar(&labelsFlag, "labels", "", "formatter labels registered outside the checked package, e.g. $#")
+	Analyzer.Flags.StringVar(&namesFlag, "names", "", "comma-separated named formatters registered outside the checked package, e.g. money,date")
+	Analyzer.Flags.StringVar(&opsFlag, "operators", "", "comma-separated expression operators registered at runtime, e.g. <>,and")
+}
+
+//formatPackage format包的导入路径
+var formatPackage = reflect.TypeOf(format.Placeholder{}).PkgPath()
+
+//formatFuncs format包中以格式化字符串为第一个参数的函数
+var formatFuncs = []string{"Fmt"}
+
+type checker struct {
+	pass   *analysis.Pass
+	funcs  []extract.Func
+	labels map[byte]bool
+	names  map[string]bool
+}
+
+var (
+	opsOnce sync.Once
+	opsErr  error
+)
+
+//registerOperators 将-operators中的操作符注册到format包。run在各个包上并发执行，注册只进行一次，
+//之后只读取format包的配置
+func registerOperators() error {
+	opsOnce.Do(func() {
+		// 检查时只需要解析表达式，操作符的优先级不影响语法检查
+		for _, symbol := range strings.Split(opsFlag, ",") {
+			if symbol = strings.TrimSpace(symbol); symbol != "" && !format.HasOperator(symbol) {
+				if err := format.RegisterOperator(symbol, format.PRECEDENCE_ADD, format.ASSOC_LEFT, nil); err != nil {
+					opsErr = err
+					return
+				}
+			}
+		}
+	})
+	return opsErr
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	c := &checker{pass: pass, labels: make(map[byte]bool), names: make(map[string]bool)}
+	for _, spec := range strings.Split(funcsFlag, ",") {
+		if spec = strings.TrimSpace(spec); spec == "" {
+			continue
+		}
+		f, err := extract.ParseFunc(spec)
+		if err != nil {
+			return nil, err
+		}
+		c.funcs = append(c.funcs, f)
+	}
+	// format包自带的格式化器
+	for _, info := range format.Formatters() {
+		if info.Name != "" {
+			c.names[info.Name] = true
+		} else {
+			c.labels[info.Label] = true
+		}
+	}
+	for i := 0; i < len(labelsFlag); i++ {
+		c.labels[labelsFlag[i]] = true
+	}
+	for _, name := range strings.Split(namesFlag, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			c.names[name] = true
+		}
+	}
+	if err := registerOperators(); err != nil {
+		return nil, err
+	}
+
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	var calls []*ast.CallExpr
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		calls = append(calls, call)
+		// 包内注册的格式化器也是已知的
+		fn := typeutil.StaticCallee(pass.TypesInfo, call)
+		if isFormatFunc(fn) && fn.Name() == "RegisterFormatter" && len(call.Args) > 0 {
+			if tv := pass.TypesInfo.Types[call.Args[0]]; tv.Value != nil {
+				if label, ok := constant.Int64Val(constant.ToInt(tv.Value)); ok {
+					c.labels[byte(label)] = true
+				}
+			}
+		}
+		if isFormatFunc(fn) && fn.Name() == "RegisterNamedFormatter" && len(call.Args) > 0 {
+			if tv := pass.TypesInfo.Types[call.Args[0]]; tv.Value != nil && tv.Value.Kind() == constant.String {
+				c.names[constant.StringVal(tv.Value)] = true
+			}
+		}
+	})
+	for _, call := range calls {
+		if fn, index, ok := c.patternIndex(call); ok {
+			c.checkCall(call, fn, index)
+		}
+	}
+	return nil, nil
+}
+
+func isFormatFunc(fn *types.Func) bool {
+	return fn != nil && fn.Pkg() != nil && fn.Pkg().Path() == formatPackage
+}
+
+//patternIndex 判断调用的函数是否需要检查，返回格式化字符串是第几个实参
+func (c *checker) patternIndex(call *ast.CallExpr) (*types.Func, int, bool) {
+	fn := typeutil.StaticCallee(c.pass.TypesInfo, call)
+	if fn == nil || fn.Pkg() == nil || fn.Type().(*types.Signature).Recv() != nil {
+		return nil, 0, false
+	}
+	if isFormatFunc(fn) {
+		for _, name := range formatFuncs {
+			if fn.Name() == name {
+				return fn, 0, true
+			}
+		}
+		return nil, 0, false
+	}
+	pkgPath := fn.Pkg().Path()
+	for _, f := range c.funcs {
+		if f.Name != fn.Name() {
+			continue
+		}
+		if (f.Package == "" && fn.Pkg() == c.pass.Pkg) || f.Package == pkgPath || f.Package == path.Base(pkgPath) {
+			return fn, f.ArgIndex, true
+		}
+	}
+	return nil, 0, false
+}
+
+//placeholderString 还原占位符在格式化字符串中的写法
+func placeholderString(p format.Placeholder) string {
+	if p.IsExpr {
+		return "{{" + p.Expr + "}}"
+	}
+	s := "{"
+	if len(p.Indices) > 0 {
+		indices := make([]string, len(p.Indices))
+		for i, index := range p.Indices {
+			indices[i] = strconv.Itoa(index)
+		}
+		s += strings.Join(indices, ",")
+	} else if p.Index >= 0 {
+		s += strconv.Itoa(p.Index)
+	}
+	if p.HasFormatter {
+		s += ":" + p.Formatter
+	}
+	if p.Filters != "" {
+		s += "|" + p.Filters
+	}
+	return s + "}"
+}
+
+func (c *checker) checkCall(call *ast.CallExpr, fn *types.Func, index int) {
+	if index >= len(call.Args) {
+		return
+	}
+	patternArg := call.Args[index]
+	tv := c.pass.TypesInfo.Types[patternArg]
+	if tv.Value == nil || tv.Value.Kind() != constant.String {
+		return
+	}
+	pattern := constant.StringVal(tv.Value)
+	name := fn.Pkg().Name() + "." + fn.Name()
+	args := call.Args[index+1:]
+	// 使用args...展开切片时无法知道参数的个数
+	argCount := len(args)
+	if call.Ellipsis.IsValid() {
+		argCount = -1
+	}
+
+	placeholders, err := format.Placeholders(pattern)
+	if err != nil {
+		c.pass.Reportf(patternArg.Pos(), "%s pattern %q has invalid syntax: %v", name, pattern, err)
+		return
+	}
+	next := 0
+	indexed, mixed := false, false
+	for _, p := range placeholders {
+		if p.IsExpr {
+			c.checkExpr(patternArg, name, p, argCount)
+			continue
+		}
+		n := formatterArgCount(p)
+		var ids []int
+		switch {
+		case len(p.Indices) > 0:
+			indexed = true
+			if len(p.Indices) != n {
+				c.pass.Reportf(patternArg.Pos(), "%s placeholder %s has %d arg indices, but its formatter takes %d args", name, placeholderString(p), len(p.Indices), n)
+				continue
+			}
+			ids = p.Indices
+		case p.Index >= 0:
+			indexed = true
+			for i := 0; i < n; i++ {
+				ids = append(ids, p.Index+i)
+			}
+		default:
+			if indexed && !mixed {
+				mixed = true
+				c.pass.Reportf(patternArg.Pos(), "%s pattern %q mixes indexed and non-indexed placeholders", name, pattern)
+			}
+			for i := 0; i < n; i++ {
+				ids = append(ids, next+i)
+			}
+			next += n
+		}
+		if i := slices.IndexFunc(ids, func(id int) bool { return argCount >= 0 && id >= argCount }); i >= 0 {
+			c.pass.Reportf(patternArg.Pos(), "%s placeholder %s refers to arg %d, but call has %d args", name, placeholderString(p), ids[i], argCount)
+			continue
+		}
+		if !p.HasFormatter {
+			continue
+		}
+		if formatter, _, ok := p.FormatterName(); ok && c.names[formatter] {
+			c.checkNamed(patternArg, name, formatter, p)
+			continue
+		} else if ok && strings.HasSuffix(p.Formatter, ")") {
+			// name(args...)的写法只用于命名格式化器
+			c.pass.Reportf(patternArg.Pos(), "%s placeholder %s uses unknown formatter %q", name, placeholderString(p), formatter)
+			continue
+		}
+		if p.Formatter == "" {
+			c.pass.Reportf(patternArg.Pos(), "%s placeholder %s has an empty formatter label", name, placeholderString(p))
+			continue
+		}
+		if !c.labels[p.Label()] {
+			c.pass.Reportf(patternArg.Pos(), "%s placeholder %s uses unknown formatter label %q", name, placeholderString(p), p.Label())
+			continue
+		}
+		// 多个参数时最后一个是被格式化的值，例如{:%*d}的第一个参数是宽度
+		if argCount >= 0 && len(ids) > 0 {
+			c.checkArg(name, p, args[ids[len(ids)-1]])
+		}
+	}
+}
+
+//formatterArgCount 占位符使用的参数个数，见format.IMultiArgFormatter，在其他地方注册的格式化器按1个计算
+func formatterArgCount(p format.Placeholder) int {
+	if !p.HasFormatter {
+		return 1
+	}
+	if f, err := format.NewFormatter(p.Formatter); err == nil {
+		if m, ok := f.(format.IMultiArgFormatter); ok {
+			return m.ArgCount()
+		}
+	}
+	return 1
+}
+
+//checkNamed 检查format包自带的命名格式化器的实参，其他地方注册的命名格式化器无法检查
+func (c *checker) checkNamed(patternArg ast.Expr, name string, formatter string, p format.Placeholder) {
+	if _, ok := format.LookupFormatter(formatter); !ok {
+		return
+	}
+	if _, err := format.NewFormatter(p.Formatter); err != nil {
+		c.pass.Reportf(patternArg.Pos(), "%s placeholder %s has invalid formatter args: %v", name, placeholderString(p), err)
+	}
+}
+
+//checkExpr 检查表达式的语法以及$N引用的参数
+func (c *checker) checkExpr(patternArg ast.Expr, name string, p format.Placeholder, argCount int) {
+	if strings.TrimSpace(p.Expr) == "" {
+		c.pass.Reportf(patternArg.Pos(), "%s expression %s is empty", name, placeholderString(p))
+		return
+	}
+	params, err := format.ParamIndices(placeholderString(p))
+	if err != nil {
+		c.pass.Reportf(patternArg.Pos(), "%s expression %s has invalid syntax: %v", name, placeholderString(p), err)
+		return
+	}
+	for _, param := range params {
+		if argCount >= 0 && param >= argCount {
+			c.pass.Reportf(patternArg.Pos(), "%s expression %s refers to $%d, but call has %d args", name, placeholderString(p), param, argCount)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package fmtcheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"github.com/Khellendros97/khutils/format"
+)
+
+//checkArg 检查参数的类型是否适合格式化器
+func (c *checker) checkArg(name string, p format.Placeholder, arg ast.Expr) {
+	tv, ok := c.pass.TypesInfo.Types[arg]
+	if !ok || tv.Type == nil {
+		return
+	}
+	spec := p.Formatter[1:]
+	switch p.Label() {
+	case format.STD_FORMATTER_LABEL:
+		if spec == "" {
+			c.pass.Reportf(arg.Pos(), "%s placeholder %s is missing a verb", name, placeholderString(p))
+			return
+		}
+		verb := spec[len(spec)-1]
+		if !stdVerbAccepts(verb, tv.Type) {
+			c.pass.Reportf(arg.Pos(), "%s placeholder %s has arg %s of wrong type %s", name, placeholderString(p), types.ExprString(arg), tv.Type)
+		}
+	case format.TIME_FORMATTER_LABEL:
+		if !timeAccepts(tv) {
+			c.pass.Reportf(arg.Pos(), "%s placeholder %s needs a time.Time, integer or numeric string, got %s of type %s", name, placeholderString(p), types.ExprString(arg), tv.Type)
+		}
+	case format.PASSWORD_FORMAT_LABEL:
+		if len(spec) > 1 {
+			if _, err := strconv.Atoi(spec[1:]); err != nil {
+				c.pass.Reportf(arg.Pos(), "%s placeholder %s has invalid length %q", name, placeholderString(p), spec[1:])
+			}
+		}
+	case format.NUMERAL_FORMATTER_LABEL, format.ORDINAL_FORMATTER_LABEL:
+		if !numberAccepts(tv) {
+			c.pass.Reportf(arg.Pos(), "%s placeholder %s needs a number or numeric string, got %s of type %s", name, placeholderString(p), types.ExprString(arg), tv.Type)
+		}
+	case format.LIST_FORMATTER_LABEL:
+		if !listAccepts(tv.Type) {
+			c.pass.Reportf(arg.Pos(), "%s placeholder %s needs a slice, array, map or iterator, got %s of type %s", name, placeholderString(p), types.ExprString(arg), tv.Type)
+		}
+	}
+}
+
+//numberAccepts 判断数字系统和序数格式化器能否格式化该值：数值或可以解析为数值的字符串
+func numberAccepts(tv types.TypeAndValue) bool {
+	if _, ok := tv.Type.Underlying().(*types.Interface); ok {
+		return true
+	}
+	basic, ok := tv.Type.Underlying().(*types.Basic)
+	if !ok {
+		return false
+	}
+	switch {
+	case basic.Info()&types.IsNumeric != 0:
+		return true
+	case basic.Info()&types.IsString != 0:
+		if tv.Value == nil {
+			return true
+		}
+		_, err := strconv.ParseFloat(constant.StringVal(tv.Value), 64)
+		return err == nil
+	}
+	return false
+}
+
+//listAccepts 判断类型是否可以遍历，见format.ListFormatter
+func listAccepts(typ types.Type) bool {
+	switch t := typ.Underlying().(type) {
+	case *types.Slice, *types.Array, *types.Map, *types.Signature, *types.Interface:
+		return true
+	case *types.Basic:
+		return t.Kind() == types.UntypedNil
+	}
+	return false
+}
+
+//hasMethod 判断类型是否实现了fmt.Stringer或error，%s等动词会调用它们
+func hasMethod(typ types.Type) bool {
+	for _, name := range []string{"String", "Error"} {
+		obj, _, _ := types.LookupFieldOrMethod(typ, true, nil, name)
+		if fn, ok := obj.(*types.Func); ok {
+			sig := fn.Type().(*types.Signature)
+			if sig.Params().Len() == 0 && sig.Results().Len() == 1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//stdVerbAccepts 判断fmt的动词是否能格式化该类型的值，只检查基本类型，无法确定时视为可以
+func stdVerbAccepts(verb byte, typ types.Type) bool {
+	if _, ok := typ.Underlying().(*types.Interface); ok {
+		return true
+	}
+	if verb == 'v' || verb == 'T' || (strings.IndexByte("sqxX", verb) >= 0 && hasMethod(typ)) {
+		return true
+	}
+	basic, ok := typ.Underlying().(*types.Basic)
+	if !ok {
+		return true
+	}
+	info := basic.Info()
+	switch verb {
+	case 'd', 'o', 'O', 'c', 'U':
+		return info&types.IsInteger != 0
+	case 'b':
+		return info&(types.IsInteger|types.IsFloat|types.IsComplex) != 0
+	case 'e', 'E', 'f', 'F', 'g', 'G':
+		return info&(types.IsFloat|types.IsComplex) != 0
+	case 'x', 'X':
+		return info&(types.IsInteger|types.IsFloat|types.IsComplex|types.IsString) != 0
+	case 's', 'q':
+		return info&types.IsString != 0 || (verb == 'q' && info&types.IsInteger != 0)
+	case 't':
+		return info&types.IsBoolean != 0
+	}
+	return true
+}
+
+//timeAccepts 判断时间格式化器能否格式化该值：time.Time、Unix时间戳整数或可以解析为整数的字符串
+func timeAccepts(tv types.TypeAndValue) bool {
+	typ := tv.Type
+	if named, ok := typ.(*types.Named); ok {
+		obj := named.Obj()
+		if obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Time" {
+			return true
+		}
+	}
+	if _, ok := typ.Underlying().(*types.Interface); ok {
+		return true
+	}
+	basic, ok := typ.Underlying().(*types.Basic)
+	if !ok {
+		return false
+	}
+	switch {
+	case basic.Info()&types.IsInteger != 0:
+		return true
+	case basic.Info()&types.IsString != 0:
+		if tv.Value == nil {
+			return true
+		}
+		_, err := strconv.ParseInt(constant.StringVal(tv.Value), 10, 64)
+		return err == nil
+	}
+	return false
+}
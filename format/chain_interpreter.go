@@ -0,0 +1,41 @@
+package format
+
+import "errors"
+
+//ChainInterpreter 依次尝试多个解释器，返回第一个成功的结果，可以用于语言回退：
+//RegisterInterpreter("Lang", Chain(zh, en))
+//只有键或命名空间不存在（见IsNotFound）时才尝试下一个解释器，其他错误直接返回，不会被回退的结果掩盖
+type ChainInterpreter struct {
+	Interpreters []IExprInterpreter
+}
+
+//Chain 创建按顺序尝试interpreters的解释器
+func Chain(interpreters ...IExprInterpreter) *ChainInterpreter {
+	return &ChainInterpreter{Interpreters: interpreters}
+}
+
+//Eval 所有解释器中都不存在该键时，返回合并后的错误
+func (c *ChainInterpreter) Eval(key string, args []any) (Value, error) {
+	return c.eval(nil, "", key, args)
+}
+
+//eval 在f中依次尝试各个解释器，使嵌套的格式化字符串共享循环检测
+func (c *ChainInterpreter) eval(f *ExprFormatter, namespace string, key string, args []any) (Value, error) {
+	var errs []error
+	for _, interpreter := range c.Interpreters {
+		value, err := evalInterpreter(f, namespace, interpreter, key, args)
+		if err == nil || !IsNotFound(err) {
+			return value, err
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return Nil, errors.New("empty interpreter chain")
+	}
+	return Nil, errors.Join(errs...)
+}
+
+func (c *ChainInterpreter) Format(key string, args []any) (string, error) {
+	value, err := c.Eval(key, args)
+	return value.String(), err
+}
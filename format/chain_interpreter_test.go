@@ -0,0 +1,41 @@
+package format
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestChainFallsThroughOnlyWhenNotFound(t *testing.T) {
+	errBoom := errors.New("boom")
+	primary := NewFuncInterpreter().
+		MustRegister("fail", func() (string, error) { return "", errBoom }).
+		MustRegister("twice", func(n int) int { return n * 2 })
+	fallback := NewMapInterpreter(map[string]string{"fail": "fallback", "twice": "fallback", "only": "fallback"})
+	chain := Chain(primary, fallback)
+
+	if _, err := chain.Eval("fail", nil); !errors.Is(err, errBoom) {
+		t.Errorf("fail: got %v, want %v", err, errBoom)
+	}
+	// 实参无法转换为int
+	if value, err := chain.Eval("twice", []any{"x"}); err == nil || IsNotFound(err) {
+		t.Errorf("twice: got %q, %v, want a conversion error", value.String(), err)
+	}
+	if value, err := chain.Eval("only", nil); err != nil || value.String() != "fallback" {
+		t.Errorf("only: got %q, %v, want fallback", value.String(), err)
+	}
+	if _, err := chain.Eval("missing", nil); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("missing: got %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestChainReturnsIncludeErrors(t *testing.T) {
+	primary := NewMapInterpreter(map[string]string{"loop": "{{chaintest::loop}}"})
+	fallback := NewMapInterpreter(map[string]string{"loop": "fallback"})
+	if err := RegisterInterpreter("chaintest", Chain(primary, fallback)); err != nil {
+		t.Fatal(err)
+	}
+	defer delete(env.exprFormatterConfig.Interpreters, "chaintest")
+	if _, err := Chain(primary, fallback).Format("loop", nil); !errors.Is(err, ErrTemplateCycle) {
+		t.Errorf("got %v, want ErrTemplateCycle", err)
+	}
+}
@@ -18,5 +18,5 @@ func (f *DefaultFormatter) Format(value any) string {
 }
 
 func (f *DefaultFormatter) ArgCount() int {
-	return 0
+	return 1
 }
@@ -0,0 +1,303 @@
+package format
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+//EACH_FORMATTER_NAME 遍历参数的格式化器，{0:each(', ')}用', '连接所有元素
+const EACH_FORMATTER_NAME = "each"
+
+//块形式的标签：{{#each $0, ', ', ' and '}}{{$index}}. {{$item}}{{else}}没有元素{{/each}}
+const (
+	EACH_BLOCK_BEGIN = "#each"
+	EACH_BLOCK_ELSE  = "else"
+	EACH_BLOCK_END   = "/each"
+)
+
+//可以遍历的值：切片、数组、map（按键排序）、iter.Seq和iter.Seq2，nil没有元素
+//@params yield 对每个元素调用，key为切片的下标、map或iter.Seq2的键，返回false时停止遍历
+func iterate(value any, yield func(key any, item any) bool) error {
+	if v, ok := value.(Value); ok {
+		if items, ok := v.raw.([]Value); ok {
+			for i, item := range items {
+				if !yield(i, item.Interface()) {
+					break
+				}
+			}
+			return nil
+		}
+		value = v.Interface()
+	}
+	if value == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if !yield(i, rv.Index(i).Interface()) {
+				break
+			}
+		}
+		return nil
+	case reflect.Map:
+		keys := rv.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			if n, err := Compare(ToValue(a.Interface()), ToValue(b.Interface())); err == nil {
+				return n
+			}
+			return cmp.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+		})
+		for _, key := range keys {
+			if !yield(key.Interface(), rv.MapIndex(key).Interface()) {
+				break
+			}
+		}
+		return nil
+	case reflect.Func:
+		return iterateSeq(rv, yield)
+	}
+	return fmt.Errorf("can not iterate %T", value)
+}
+
+//iterateSeq 遍历iter.Seq[V]或iter.Seq2[K, V]，iter.Seq的键是元素的序号
+func iterateSeq(rv reflect.Value, yield func(key any, item any) bool) error {
+	t := rv.Type()
+	if t.NumIn() != 1 || t.NumOut() != 0 {
+		return fmt.Errorf("can not iterate %s", t)
+	}
+	yieldType := t.In(0)
+	if yieldType.Kind() != reflect.Func || yieldType.NumOut() != 1 || yieldType.Out(0).Kind() != reflect.Bool ||
+		yieldType.NumIn() < 1 || yieldType.NumIn() > 2 {
+		return fmt.Errorf("can not iterate %s", t)
+	}
+	index := 0
+	fn := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+		var ok bool
+		if len(args) == 1 {
+			ok = yield(index, args[0].Interface())
+		} else {
+			ok = yield(args[0].Interface(), args[1].Interface())
+		}
+		index++
+		return []reflect.Value{reflect.ValueOf(ok)}
+	})
+	rv.Call([]reflect.Value{fn})
+	return nil
+}
+
+//joinItems 连接字符串，最后两个元素之间使用last，last为空时使用sep
+func joinItems(items []string, sep string, last string) string {
+	if len(items) < 2 || last == "" {
+		return strings.Join(items, sep)
+	}
+	return strings.Join(items[:len(items)-1], sep) + last + items[len(items)-1]
+}
+
+//EachFormatter 连接切片、数组、map、iter.Seq的元素
+//{0:each}直接连接，{0:each(', ')}使用分隔符，{0:each(', ', ' and ')}最后两个元素之间使用' and '，
+//{0:each(', ', ' and ', 'none')}没有元素时输出'none'
+type EachFormatter struct {
+	sep   string
+	last  string
+	empty string
+}
+
+func NewEachFormatter() IValueFormatter {
+	return &EachFormatter{}
+}
+
+//eachParams each格式化器的参数声明
+var eachParams = []FormatterParam{
+	{Name: "sep", Kind: VALUE_STRING, Optional: true, Doc: "separator between items"},
+	{Name: "last", Kind: VALUE_STRING, Optional: true, Doc: "separator between the last two items, sep if empty"},
+	{Name: "empty", Kind: VALUE_STRING, Optional: true, Doc: "output when there are no items"},
+}
+
+//Parse 解析逗号分隔的实参，与{0:each(...)}括号中的内容相同，例如', ', ' and '
+func (f *EachFormatter) Parse(token string) (err error) {
+	values, err := parseFormatterArgs(token)
+	if err != nil {
+		return
+	}
+	args, err := bindFormatterArgs(FormatterInfo{Name: EACH_FORMATTER_NAME, Params: eachParams}, values)
+	if err != nil {
+		return
+	}
+	return f.ParseArgs(args)
+}
+
+func (f *EachFormatter) ParseArgs(args FormatterArgs) error {
+	f.sep, f.last, f.empty = args["sep"].String(), args["last"].String(), args["empty"].String()
+	return nil
+}
+
+func (f *EachFormatter) Format(value any) string {
+	var items []string
+	err := iterate(value, func(_ any, item any) bool {
+		items = append(items, fmt.Sprintf("%v", item))
+		return true
+	})
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	if len(items) == 0 {
+		return f.empty
+	}
+	return joinItems(items, f.sep, f.last)
+}
+
+func (f *EachFormatter) ArgCount() int {
+	return 1
+}
+
+//tokenLocal 块中绑定的变量，例如$item、$index、$key
+type tokenLocal string
+
+func (l *tokenLocal) Eval(env *ExprFormatter) (Value, error) {
+	if value, ok := env.locals[string(*l)]; ok {
+		return ToValue(value), nil
+	}
+	return Nil, fmt.Errorf("undefined variable: $%s", string(*l))
+}
+
+//eachBlock {{#each collection[, sep[, last]]}}body{{else}}empty{{/each}}
+type eachBlock struct {
+	collection Expr
+	sep        Expr
+	last       Expr
+	body       string
+	empty      string
+}
+
+//parseBlockTag 解析块的标签，tag为{{}}中的内容。不是块的标签时isBlock为false；
+//开始标签返回其中以逗号分隔的表达式，{{else}}和{{/each}}没有表达式
+func (f *ExprFormatterConfig) parseBlockTag(tag string) (params []Expr, isBlock bool, err error) {
+	tag = strings.TrimSpace(tag)
+	if tag == EACH_BLOCK_ELSE || tag == EACH_BLOCK_END {
+		return nil, true, nil
+	}
+	rest, ok := strings.CutPrefix(tag, EACH_BLOCK_BEGIN)
+	if !ok {
+		return nil, false, nil
+	}
+	p := f.NewParser(rest)
+	for {
+		param, err := p.parseExpr(0)
+		if err != nil {
+			return nil, true, err
+		}
+		params = append(params, param)
+		p.skipSpace()
+		if ok, _ := p.Require(isChar(',')); !ok {
+			break
+		}
+		p.pos++
+	}
+	if p.pos < len(p.expr) {
+		return nil, true, p.errorf("unexpected %q", p.residue())
+	}
+	if len(params) > 3 {
+		return nil, true, fmt.Errorf("each: expected at most 3 args, got %d", len(params))
+	}
+	return params, true, nil
+}
+
+//parseEachBlock 解析块，tag为开始标签{{}}中的内容，rest为开始标签之后的格式化字符串，
+//返回块以及结束标签之后的内容在rest中的位置。开始标签有错误时仍然返回结束标签的位置，以便跳过整个块
+func (f *ExprFormatterConfig) parseEachBlock(tag string, rest string) (*eachBlock, int, error) {
+	params, _, tagErr := f.parseBlockTag(tag)
+	block := &eachBlock{}
+	if tagErr == nil {
+		block.collection = params[0]
+		if len(params) > 1 {
+			block.sep = params[1]
+		}
+		if len(params) > 2 {
+			block.last = params[2]
+		}
+	}
+	// 查找与开始标签匹配的{{else}}和{{/each}}，块可以嵌套
+	depth := 0
+	elsePos, elseEnd := -1, -1
+	for pos := 0; ; {
+		start := strings.Index(rest[pos:], "{{")
+		if start < 0 {
+			return nil, 0, fmt.Errorf("each: missing {{%s}}", EACH_BLOCK_END)
+		}
+		start += pos
+		end := strings.Index(rest[start:], "}}")
+		if end < 0 {
+			return nil, 0, fmt.Errorf("each: missing {{%s}}", EACH_BLOCK_END)
+		}
+		end += start + 2
+		switch content := strings.TrimSpace(rest[start+2 : end-2]); {
+		case strings.HasPrefix(content, EACH_BLOCK_BEGIN):
+			depth++
+		case content == EACH_BLOCK_END && depth > 0:
+			depth--
+		case content == EACH_BLOCK_END:
+			if tagErr != nil {
+				return nil, end, tagErr
+			}
+			if elsePos >= 0 {
+				block.body, block.empty = rest[:elsePos], rest[elseEnd:start]
+			} else {
+				block.body = rest[:start]
+			}
+			return block, end, nil
+		case content == EACH_BLOCK_ELSE && depth == 0:
+			elsePos, elseEnd = start, end
+		}
+		pos = end
+	}
+}
+
+//optionalString 对可以省略的表达式求值
+func optionalString(env *ExprFormatter, ex Expr) (string, error) {
+	if ex == nil {
+		return "", nil
+	}
+	value, err := ex.Eval(env)
+	return value.String(), err
+}
+
+//render 对每个元素渲染块的内容，其中$item为元素，$index为序号，$key为下标或map的键
+func (b *eachBlock) render(env *ExprFormatter) (string, error) {
+	collection, err := b.collection.Eval(env)
+	if err != nil {
+		return "", err
+	}
+	sep, err := optionalString(env, b.sep)
+	if err != nil {
+		return "", err
+	}
+	last, err := optionalString(env, b.last)
+	if err != nil {
+		return "", err
+	}
+	var items []string
+	var errs error
+	index := 0
+	err = iterate(collection, func(key any, item any) bool {
+		child := env.child(env.Args)
+		child.locals["item"], child.locals["index"], child.locals["key"] = item, index, key
+		str, err := render(b.body, child)
+		items = append(items, str)
+		errs = errors.Join(errs, err)
+		index++
+		return true
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(items) == 0 {
+		return render(b.empty, env.child(env.Args))
+	}
+	return joinItems(items, sep, last), errs
+}
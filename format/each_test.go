@@ -0,0 +1,30 @@
+package format
+
+import "testing"
+
+func TestEachBlockTagErrors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"{{#each}}x{{/each}}!", "!"},
+		{"a{{#each 1 +}}x{{else}}y{{/each}}b", "ab"},
+		{"{{#each $0, ', '}}{{$item}}{{/each}}", "1, 2"},
+	}
+	for _, test := range tests {
+		if got := Fmt(test.pattern, []int{1, 2}); got != test.want {
+			t.Errorf("Fmt(%q) = %q, want %q", test.pattern, got, test.want)
+		}
+	}
+	for _, pattern := range []string{"{{#each}}x{{/each}}", "a{{/each}}b", "a{{else}}b"} {
+		if _, err := Placeholders(pattern); err == nil {
+			t.Errorf("Placeholders(%q) returned no error", pattern)
+		}
+		if _, err := render(pattern, NewExprFormatter(env.exprFormatterConfig)); err == nil {
+			t.Errorf("render(%q) returned no error", pattern)
+		}
+	}
+	if _, err := Placeholders("{{#each $0}}x"); err == nil {
+		t.Errorf("Placeholders returned no error for a block without {{/each}}")
+	}
+}
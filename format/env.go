@@ -2,23 +2,29 @@ package format
 
 type FormatEnv struct {
 	valFormatters map[byte]func()IValueFormatter
+	namedFormatters map[string]*namedFormatter
 	exprFormatterConfig *ExprFormatterConfig
 }
 
 var env *FormatEnv = &FormatEnv{
 	valFormatters: make(map[byte]func()IValueFormatter),
+	namedFormatters: make(map[string]*namedFormatter),
 	exprFormatterConfig: NewExprFormatterConfig(),
 }
 
-//RegisterFormatter 注册一个格式化器
+//defaultLocale 格式化器和过滤器的默认语言，见SetLocale
+var defaultLocale = "en"
+
+//RegisterFormatter 注册一个格式化器，需要多个字符的名字或结构化的实参时使用RegisterNamedFormatter
 //@params key 格式化器的标签，单个字符; getFormatter 格式化器的工厂函数
 func RegisterFormatter(key byte, getFormatter func()IValueFormatter) {
 	env.valFormatters[key] = getFormatter
 }
 
 //RegisterInterpreter 注册一个表达式解释器
-func RegisterInterpreter(key string, interpreter IExprInterpreter) {
-	env.exprFormatterConfig.Interpreters[key] = interpreter
+//命名空间tpl保留给模板（见Define），注册tpl时返回错误
+func RegisterInterpreter(key string, interpreter IExprInterpreter) error {
+	return env.exprFormatterConfig.Register(key, interpreter)
 }
 
 //SetDefaultInterpreter 设置默认的表达式解释器
@@ -26,13 +32,102 @@ func SetDefaultInterpreter(key string) {
 	env.exprFormatterConfig.DefaultInter = key
 }
 
-//SetOperate 添加自定义操作符（默认自带+操作符，直接连接字符串）
+//SetOperate 添加自定义操作符（默认自带+操作符，数值相加，其他值直接连接字符串）
+//新的操作符使用PRECEDENCE_ADD和左结合，需要指定优先级和结合性时使用RegisterOperator
 func SetOperate(key byte, fn func(s1, s2 string) string) {
-	env.exprFormatterConfig.BinOps[key] = fn
+	env.exprFormatterConfig.SetFnConcat(key, fn)
+}
+
+//RegisterOperator 注册二元操作符，可以是多个字符
+//@params symbol 操作符; precedence 优先级，参考PRECEDENCE_*常量; assoc 结合性
+func RegisterOperator(symbol string, precedence int, assoc Associativity, fn func(s1, s2 string) string) error {
+	return env.exprFormatterConfig.RegisterOperator(symbol, precedence, assoc, fn)
+}
+
+//RegisterUnaryOperator 注册前缀一元操作符
+func RegisterUnaryOperator(symbol string, fn func(s string) string) error {
+	return env.exprFormatterConfig.RegisterUnaryOperator(symbol, fn)
+}
+
+//HasOperator 是否已注册该二元操作符，包括内置的??、&&和||
+func HasOperator(symbol string) bool {
+	if _, ok := builtinOperators[symbol]; ok {
+		return true
+	}
+	_, ok := env.exprFormatterConfig.Operators[symbol]
+	return ok
+}
+
+//RegisterOperatorFunc 为已注册的二元操作符添加按操作数类型调用的函数，VALUE_ANY匹配任意类型
+func RegisterOperatorFunc(symbol string, left ValueKind, right ValueKind, fn OperatorFunc) error {
+	return env.exprFormatterConfig.RegisterOperatorFunc(symbol, left, right, fn)
+}
+
+//RegisterUnaryOperatorFunc 为一元操作符添加按操作数类型调用的函数
+func RegisterUnaryOperatorFunc(symbol string, kind ValueKind, fn UnaryOperatorFunc) error {
+	return env.exprFormatterConfig.RegisterUnaryOperatorFunc(symbol, kind, fn)
+}
+
+//RegisterFilter 注册过滤器，内置的过滤器有upper、lower、title、trim、trunc、default、replace、json、urlencode、html、list
+//@params name 过滤器的名字，可以包含字母、数字、下划线和.
+func RegisterFilter(name string, filter Filter) error {
+	return env.exprFormatterConfig.RegisterFilter(name, filter)
+}
+
+//Define 定义模板，在格式化字符串中用{{> name}}或{{tpl::name(args...)}}插入
+//Define("footer", "-- {0}")后，Fmt("Hi{{> footer}}", "Bob") => "Hi-- Bob"
+func Define(name string, pattern string) error {
+	return env.exprFormatterConfig.Define(name, pattern)
+}
+
+//SetMaxIncludeDepth 设置模板嵌套插入的最大层数，默认为DEFAULT_MAX_INCLUDE_DEPTH
+func SetMaxIncludeDepth(depth int) {
+	env.exprFormatterConfig.MaxIncludeDepth = depth
+}
+
+//SetMissingKeyPolicy 设置表达式引用的键或命名空间不存在时的处理方式，默认为MISSING_KEY_ERROR
+func SetMissingKeyPolicy(policy MissingKeyPolicy) {
+	env.exprFormatterConfig.MissingKey = policy
+}
+
+//SetMissingKeyMarker 设置MISSING_KEY_MARKER策略输出的标记，%s为引用的名字，例如"??%s??"
+func SetMissingKeyMarker(marker string) {
+	env.exprFormatterConfig.MissingKeyMarker = marker
+}
+
+//SetMissingKeyHook 设置键不存在时调用的函数，并切换到MISSING_KEY_HOOK策略
+//hook可以返回替代的文本，也可以返回错误
+func SetMissingKeyHook(hook func(namespace string, key string, args []any) (string, error)) {
+	env.exprFormatterConfig.MissingKeyHook = hook
+	env.exprFormatterConfig.MissingKey = MISSING_KEY_HOOK
+}
+
+//SetLocale 设置格式化器的默认语言，默认为en，例如列表格式化器{0:&}和序数格式化器{0:^}使用的语言
+func SetLocale(locale string) {
+	defaultLocale = locale
+}
+
+//Locale 返回格式化器的默认语言
+func Locale() string {
+	return defaultLocale
 }
 
 func init() {
 	RegisterFormatter(STD_FORMATTER_LABEL, NewStdFormatter)
 	RegisterFormatter(TIME_FORMATTER_LABEL, NewTimeFormatter)
 	RegisterFormatter(PASSWORD_FORMAT_LABEL, NewPasswordFormatter)
+	RegisterFormatter(LIST_FORMATTER_LABEL, NewListFormatter)
+	RegisterFormatter(NUMERAL_FORMATTER_LABEL, NewNumeralFormatter)
+	RegisterFormatter(ORDINAL_FORMATTER_LABEL, NewOrdinalFormatter)
+	RegisterNamedFormatter(EACH_FORMATTER_NAME, NewEachFormatter, eachParams...)
+	RegisterNamedFormatter(TIME_FORMATTER_NAME, NewTimeFormatter, FormatterParam{Name: "layout", Kind: VALUE_STRING, Optional: true,
+		Default: StringValue("datetime"), Doc: "datetime, date, time or a quoted layout such as 'Y-M-D h:m'"})
+	RegisterNamedFormatter(RANGE_FORMATTER_NAME, NewRangeFormatter, rangeParams...)
+	RegisterNamedFormatter(RATIO_FORMATTER_NAME, NewRatioFormatter, ratioParams...)
+	RegisterNamedFormatter(LIST_FORMATTER_NAME, NewListFormatter, FormatterParam{Name: "options", Kind: VALUE_STRING, Variadic: true,
+		Doc: "locale, and/or/unit, short/narrow"})
+	RegisterNamedFormatter(NUMERAL_FORMATTER_NAME, NewNumeralFormatter, FormatterParam{Name: "options", Kind: VALUE_STRING, Variadic: true,
+		Doc: "numbering system such as roman, hans or fullwide, group, %verb"})
+	RegisterNamedFormatter(ORDINAL_FORMATTER_NAME, NewOrdinalFormatter, FormatterParam{Name: "options", Kind: VALUE_STRING, Variadic: true,
+		Doc: "locale, numbering system, group"})
 }
\ No newline at end of file
@@ -1,27 +1,63 @@
 package format
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+//MissingKeyPolicy 表达式引用的变量、函数或命名空间不存在时的处理方式
+type MissingKeyPolicy int
+
+const (
+	MISSING_KEY_ERROR      MissingKeyPolicy = iota // 作为错误处理，Fmt不输出该表达式
+	MISSING_KEY_RENDER_KEY                         // 输出引用的名字，例如hello
+	MISSING_KEY_MARKER                             // 输出标记，默认为[missing: hello]
+	MISSING_KEY_HOOK                               // 调用MissingKeyHook
+)
+
+//DEFAULT_MISSING_KEY_MARKER 默认的缺失标记，%s为引用的名字
+const DEFAULT_MISSING_KEY_MARKER = "[missing: %s]"
 
 type ExprFormatterConfig struct {
 	Interpreters map[string]IExprInterpreter
 	DefaultInter string
-	BinOps       map[byte]func(s1, s2 string) string
+	Operators    map[string]*BinaryOperator // 二元操作符，??由解析器内置处理
+	UnaryOps     map[string]*UnaryOperator  // 前缀一元操作符
+	Filters      map[string]Filter          // 过滤器，{0|upper}和{{ x | upper }}使用
+	Templates    map[string]string          // 用Define定义的模板
+
+	MaxIncludeDepth int // 模板嵌套插入的最大层数
+
+	MissingKey       MissingKeyPolicy
+	MissingKeyMarker string                                                         // MISSING_KEY_MARKER使用的标记，%s为引用的名字
+	MissingKeyHook   func(namespace string, key string, args []any) (string, error) // MISSING_KEY_HOOK调用的函数
 }
 
 func NewExprFormatterConfig() *ExprFormatterConfig {
-	return &ExprFormatterConfig{
-		Interpreters: make(map[string]IExprInterpreter),
-		BinOps: map[byte]func(s1, s2 string) string{
-			'+': func(s1, s2 string) string {
-				return s1 + s2
-			},
-		},
+	config := &ExprFormatterConfig{
+		Interpreters:     make(map[string]IExprInterpreter),
+		Operators:        make(map[string]*BinaryOperator),
+		UnaryOps:         make(map[string]*UnaryOperator),
+		Filters:          make(map[string]Filter),
+		Templates:        make(map[string]string),
+		MaxIncludeDepth:  DEFAULT_MAX_INCLUDE_DEPTH,
+		MissingKeyMarker: DEFAULT_MISSING_KEY_MARKER,
 	}
+	config.registerBuiltinOperators()
+	config.registerBuiltinFilters()
+	return config
 }
 
 type ExprFormatter struct {
 	*ExprFormatterConfig
-	Args         []any
+	Args []any
+
+	coalescing int            // 正在对??的左侧求值，此时缺失的键作为错误返回，由??处理
+	includes   []string       // 正在渲染的模板，用于检测循环插入
+	locals     map[string]any // 块中绑定的变量，例如{{#each}}中的$item
 }
 
 func NewExprFormatter(config *ExprFormatterConfig) *ExprFormatter {
@@ -30,18 +66,19 @@ func NewExprFormatter(config *ExprFormatterConfig) *ExprFormatter {
 	}
 }
 
-func (f *ExprFormatterConfig) Register(name string, interpreter IExprInterpreter) {
+//Register 注册表达式解释器，命名空间tpl保留给模板
+func (f *ExprFormatterConfig) Register(name string, interpreter IExprInterpreter) error {
+	if name == TEMPLATE_NAMESPACE {
+		return fmt.Errorf("namespace %s is reserved for templates", TEMPLATE_NAMESPACE)
+	}
 	f.Interpreters[name] = interpreter
+	return nil
 }
 
 func (f *ExprFormatterConfig) SetDefault(name string) {
 	f.DefaultInter = name
 }
 
-func (f *ExprFormatterConfig) SetFnConcat(key byte, fn func(s1, s2 string) string) {
-	f.BinOps[key] = fn
-}
-
 func (f *ExprFormatter) GetArg(index int) (any, error) {
 	if index < len(f.Args) {
 		return f.Args[index], nil
@@ -49,23 +86,112 @@ func (f *ExprFormatter) GetArg(index int) (any, error) {
 	return nil, fmt.Errorf("Argument index out of range: %d", index)
 }
 
-func (f *ExprFormatter) EvalVar(namespace string, key string, args []any) (string, error) {
+//child 创建渲染模板或块使用的求值环境，继承正在渲染的模板和绑定的变量
+func (f *ExprFormatter) child(args []any) *ExprFormatter {
+	c := &ExprFormatter{
+		ExprFormatterConfig: f.ExprFormatterConfig,
+		Args:                args,
+		includes:            slices.Clip(f.includes),
+		locals:              make(map[string]any, len(f.locals)+3),
+	}
+	maps.Copy(c.locals, f.locals)
+	return c
+}
+
+//evalInterpreter 调用解释器求值，实现了IValueInterpreter的解释器返回带类型的值，
+//实现了IPatternInterpreter的解释器在f中渲染，f为nil时使用默认配置
+func evalInterpreter(f *ExprFormatter, namespace string, interpreter IExprInterpreter, key string, args []any) (Value, error) {
+	switch i := interpreter.(type) {
+	case *ChainInterpreter:
+		return i.eval(f, namespace, key, args)
+	case IPatternInterpreter:
+		if f == nil {
+			f = NewExprFormatter(env.exprFormatterConfig)
+		}
+		name := key
+		if namespace != "" {
+			name = namespace + "::" + key
+		}
+		return f.evalPattern(i, name, key, args)
+	case IValueInterpreter:
+		return i.Eval(key, args)
+	}
+	str, err := interpreter.Format(key, args)
+	if err != nil {
+		return Nil, err
+	}
+	return StringValue(str), nil
+}
+
+func (f *ExprFormatter) EvalVar(namespace string, key string, args []any) (Value, error) {
 	if namespace == "" {
 		namespace = f.DefaultInter
 	}
+	var value Value
+	var err error
+	list := loadObservers()
+	var start time.Time
+	if list != nil {
+		start = time.Now()
+	}
 	module := f.Interpreters[namespace]
 	if module == nil {
-		return "", fmt.Errorf("Unknown namespace: %s", namespace)
+		err = fmt.Errorf("%w: %s", ErrNamespaceNotFound, namespace)
+	} else {
+		value, err = evalInterpreter(f, namespace, module, key, args)
+	}
+	if list != nil {
+		e := LookupEvent{Namespace: namespace, Key: key, Hit: err == nil, Err: err, Duration: time.Since(start)}
+		if li, ok := module.(ILocaleInterpreter); ok {
+			e.Locale = li.Locale()
+		}
+		notifyLookup(list, e)
+	}
+	if err != nil && IsNotFound(err) && f.coalescing == 0 {
+		return f.missingKey(namespace, key, args, err)
 	}
-	return module.Format(key, args)
+	return value, err
 }
 
-func (f *ExprFormatter) Eval(expr string) (str string, err error) {
-	parser := NewExprParser(expr)
+//missingKey 按MissingKey策略处理缺失的键
+func (f *ExprFormatter) missingKey(namespace string, key string, args []any, err error) (Value, error) {
+	name := key
+	if namespace != "" && namespace != f.DefaultInter {
+		name = namespace + "::" + key
+	}
+	switch f.MissingKey {
+	case MISSING_KEY_RENDER_KEY:
+		return StringValue(name), nil
+	case MISSING_KEY_MARKER:
+		return StringValue(fmt.Sprintf(f.MissingKeyMarker, name)), nil
+	case MISSING_KEY_HOOK:
+		if f.MissingKeyHook == nil {
+			return Nil, errors.New("missing key hook is not set")
+		}
+		str, err := f.MissingKeyHook(namespace, key, args)
+		if err != nil {
+			return Nil, err
+		}
+		return StringValue(str), nil
+	}
+	return Nil, err
+}
+
+//EvalValue 对表达式求值，返回带类型的值
+func (f *ExprFormatter) EvalValue(expr string) (Value, error) {
+	parser := f.NewParser(expr)
 	ex, err := parser.ParseExpr()
 	if err != nil {
-		return
+		return Nil, err
 	}
-	//fmt.Println("eval", ex)
 	return ex.Eval(f)
 }
+
+//Eval 对表达式求值，结果只在这里转换为写入输出的字符串
+func (f *ExprFormatter) Eval(expr string) (str string, err error) {
+	value, err := f.EvalValue(expr)
+	if err != nil {
+		return
+	}
+	return value.String(), nil
+}
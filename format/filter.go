@@ -0,0 +1,175 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Khellendros97/khutils/list"
+)
+
+//Filter 过滤器，对占位符或表达式的值进行变换，例如{0|upper|trunc(20)}、{{ Lang::title | lower }}
+//@params value 输入值，占位符使用了格式化器时为格式化的结果; args 过滤器的实参，例如trunc(20)中的20
+type Filter func(value Value, args []Value) (Value, error)
+
+//RegisterFilter 注册过滤器，替换同名的过滤器
+func (f *ExprFormatterConfig) RegisterFilter(name string, filter Filter) error {
+	if !isLabel(name) {
+		return fmt.Errorf("invalid filter name: %q", name)
+	}
+	f.Filters[name] = filter
+	return nil
+}
+
+//applyFilter 调用名为name的过滤器
+func (f *ExprFormatterConfig) applyFilter(name string, value Value, args []Value) (Value, error) {
+	filter, ok := f.Filters[name]
+	if !ok {
+		return Nil, fmt.Errorf("unknown filter: %s", name)
+	}
+	value, err := filter(value, args)
+	if err != nil {
+		return Nil, fmt.Errorf("filter %s: %w", name, err)
+	}
+	return value, nil
+}
+
+//FormatterFilter 将格式化器包装为过滤器，过滤器的第一个实参是格式化器的参数（不包含标签）：
+//RegisterFilter("money", FormatterFilter(NewStdFormatter))后，{{ $0 | money('.2f') }}与{0:%.2f}相同
+func FormatterFilter(getFormatter func() IValueFormatter) Filter {
+	return func(value Value, args []Value) (Value, error) {
+		formatter := getFormatter()
+		spec := ""
+		if len(args) > 0 {
+			spec = args[0].String()
+		}
+		if err := formatter.Parse(spec); err != nil {
+			return Nil, err
+		}
+		return StringValue(formatter.Format(value.Interface())), nil
+	}
+}
+
+//checkArgs 检查过滤器实参的个数
+func checkArgs(args []Value, min int, max int) error {
+	if len(args) < min || len(args) > max {
+		if min == max {
+			return fmt.Errorf("expected %d args, got %d", min, len(args))
+		}
+		return fmt.Errorf("expected %d to %d args, got %d", min, max, len(args))
+	}
+	return nil
+}
+
+//stringFilter 不接受实参的字符串变换
+func stringFilter(fn func(s string) string) Filter {
+	return func(value Value, args []Value) (Value, error) {
+		if err := checkArgs(args, 0, 0); err != nil {
+			return Nil, err
+		}
+		return StringValue(fn(value.String())), nil
+	}
+}
+
+//title 将每个单词的首字母转换为大写
+func title(s string) string {
+	var sb strings.Builder
+	start := true
+	for _, r := range s {
+		if start && unicode.IsLetter(r) {
+			sb.WriteRune(unicode.ToTitle(r))
+		} else {
+			sb.WriteRune(r)
+		}
+		start = unicode.IsSpace(r) || r == '-'
+	}
+	return sb.String()
+}
+
+//registerBuiltinFilters 注册内置的过滤器：
+//upper、lower、title、trim([cutset])、trunc(n[, suffix])、default(value)、replace(old, new)、json、urlencode、html、list([options...])
+func (f *ExprFormatterConfig) registerBuiltinFilters() {
+	f.RegisterFilter("upper", stringFilter(strings.ToUpper))
+	f.RegisterFilter("lower", stringFilter(strings.ToLower))
+	f.RegisterFilter("title", stringFilter(title))
+	f.RegisterFilter("urlencode", stringFilter(url.QueryEscape))
+	f.RegisterFilter("html", stringFilter(html.EscapeString))
+	f.RegisterFilter("trim", func(value Value, args []Value) (Value, error) {
+		if err := checkArgs(args, 0, 1); err != nil {
+			return Nil, err
+		}
+		if len(args) == 1 {
+			return StringValue(strings.Trim(value.String(), args[0].String())), nil
+		}
+		return StringValue(strings.TrimSpace(value.String())), nil
+	})
+	// 按字符截断，截断时追加suffix，例如trunc(20, '...')
+	f.RegisterFilter("trunc", func(value Value, args []Value) (Value, error) {
+		if err := checkArgs(args, 1, 2); err != nil {
+			return Nil, err
+		}
+		n, ok := args[0].Int()
+		if !ok || n < 0 {
+			return Nil, fmt.Errorf("invalid length: %s", args[0])
+		}
+		s := value.String()
+		if int64(utf8.RuneCountInString(s)) <= n {
+			return StringValue(s), nil
+		}
+		runes := []rune(s)
+		suffix := ""
+		if len(args) == 2 {
+			suffix = args[1].String()
+		}
+		return StringValue(string(runes[:n]) + suffix), nil
+	})
+	// 值为假时（见Value.Truthy）使用实参，键不存在时应该使用??
+	f.RegisterFilter("default", func(value Value, args []Value) (Value, error) {
+		if err := checkArgs(args, 1, 1); err != nil {
+			return Nil, err
+		}
+		if value.Truthy() {
+			return value, nil
+		}
+		return args[0], nil
+	})
+	f.RegisterFilter("replace", func(value Value, args []Value) (Value, error) {
+		if err := checkArgs(args, 2, 2); err != nil {
+			return Nil, err
+		}
+		return StringValue(strings.ReplaceAll(value.String(), args[0].String(), args[1].String())), nil
+	})
+	f.RegisterFilter("json", func(value Value, args []Value) (Value, error) {
+		if err := checkArgs(args, 0, 0); err != nil {
+			return Nil, err
+		}
+		data, err := json.Marshal(value.Interface())
+		if err != nil {
+			return Nil, err
+		}
+		return StringValue(string(data)), nil
+	})
+	// 按CLDR列表模式连接元素，选项与列表格式化器相同，例如list('fr', 'or')、list('unit', 'narrow')
+	f.RegisterFilter("list", func(value Value, args []Value) (Value, error) {
+		options := make([]string, len(args))
+		for i, arg := range args {
+			options[i] = arg.String()
+		}
+		locale, t, w, err := parseListOptions(options)
+		if err != nil {
+			return Nil, err
+		}
+		items, err := listItems(value)
+		if err != nil {
+			return Nil, err
+		}
+		if locale == "" {
+			locale = Locale()
+		}
+		return StringValue(list.Format(locale, items, t, w)), nil
+	})
+}
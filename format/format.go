@@ -1,52 +1,164 @@
 package format
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type format struct {
 	sb strings.Builder
 	count int
 	formatter IValueFormatter
+	label byte
+	name string
+	spec string
+	filters string
 	args []any
 	lastState FormatState
 	iter *FormatIter
 	exprFormatter *ExprFormatter
+	exprErr error // 求值失败的表达式的错误
 }
 
-func (f *format) formatValue(index int) (err error) {
-	id := index
-	if index < 0 {
+//parseIndices 解析占位符的参数索引，多个索引用','分隔，例如0,1
+func parseIndices(token string) ([]int, error) {
+	var indices []int
+	for _, s := range strings.Split(token, ",") {
+		index, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid arg index: %q", token)
+		}
+		indices = append(indices, index)
+	}
+	return indices, nil
+}
+
+//bindArgs 返回占位符使用的n个参数的下标：省略索引时使用接下来的n个参数，只有一个索引时使用从它开始的n个参数，
+//多个索引时索引的个数必须为n
+func (f *format) bindArgs(indices []int, n int) (ids []int, err error) {
+	switch {
+	case len(indices) == 0:
 		if f.count < 0 {
 			err = fmt.Errorf("can not mix indexed param with non-indexed param")
 			return
 		}
-		id = f.count
-	} else {
+		for i := 0; i < n; i++ {
+			ids = append(ids, f.count + i)
+		}
+	case len(indices) == 1:
 		f.count = -1
+		for i := 0; i < n; i++ {
+			ids = append(ids, indices[0] + i)
+		}
+	default:
+		f.count = -1
+		if len(indices) != n {
+			err = fmt.Errorf("placeholder has %d arg indices, but formatter takes %d args", len(indices), n)
+			return
+		}
+		ids = indices
+	}
+	for _, id := range ids {
+		if id >= len(f.args) {
+			err = fmt.Errorf("arg index out of range")
+			return
+		}
+	}
+	return
+}
+
+func (f *format) formatValue(indices []int) (err error) {
+	n := 1
+	multi, isMulti := f.formatter.(IMultiArgFormatter)
+	if isMulti {
+		n = multi.ArgCount()
 	}
-	if id >= len(f.args) {
-		err = fmt.Errorf("arg index out of range")
+	ids, err := f.bindArgs(indices, n)
+	if err != nil {
 		return
 	}
 	var str string
-	if f.formatter == nil {
-		str = fmt.Sprintf("%v", f.args[id])
+	list := loadObservers()
+	var start time.Time
+	if list != nil {
+		start = time.Now()
+	}
+	if isMulti {
+		args := make([]any, len(ids))
+		for i, id := range ids {
+			args[i] = f.args[id]
+		}
+		str = multi.FormatArgs(args)
+	} else if f.formatter == nil {
+		str = fmt.Sprintf("%v", f.args[ids[0]])
 	} else {
-		str = f.formatter.Format(f.args[id])
+		str = f.formatter.Format(f.args[ids[0]])
+	}
+	if list != nil {
+		notifyFormat(list, FormatterEvent{Label: f.label, Name: f.name, Spec: f.spec, Duration: time.Since(start)})
+	}
+	if f.filters != "" {
+		str, err = f.applyFilters(ids, str)
+		if err != nil {
+			return
+		}
 	}
 	
 	f.sb.WriteString(str)
-	if index < 0 {
-		f.count++
+	if len(indices) == 0 {
+		f.count += n
 	}
 	return
 }
 
+//applyFilters 依次调用占位符的过滤器，使用了格式化器时过滤器的输入是格式化的结果，否则是参数本身
+func (f *format) applyFilters(ids []int, str string) (string, error) {
+	filters, err := f.exprFormatter.NewParser(f.filters).parseFilters()
+	if err != nil {
+		return "", err
+	}
+	value := StringValue(str)
+	if f.formatter == nil {
+		value = ToValue(f.args[ids[0]])
+	}
+	for _, filter := range filters {
+		value, err = filter.apply(f.exprFormatter, value)
+		if err != nil {
+			return "", err
+		}
+	}
+	return value.String(), nil
+}
+
+//formatBlock 渲染{{#each}}块，并让迭代器跳过块的内容和结束标签
+func (f *format) formatBlock(tag string) (string, error) {
+	if !strings.HasPrefix(strings.TrimSpace(tag), EACH_BLOCK_BEGIN) {
+		return "", fmt.Errorf("unexpected {{%s}}", strings.TrimSpace(tag))
+	}
+	// 迭代器位于开始标签的第一个'}'之后
+	start := f.iter.pos + 1
+	if start > len(f.iter.input) || f.iter.input[f.iter.pos] != '}' {
+		return "", fmt.Errorf("unterminated block")
+	}
+	block, end, err := f.exprFormatter.parseEachBlock(tag, string(f.iter.input[start:]))
+	if err != nil {
+		// 开始标签有错误时跳过整个块，块的内容不会作为字面量输出
+		if end > 0 {
+			f.iter.skip(end)
+		}
+		return "", err
+	}
+	// 停在结束标签的最后一个'}'上，由迭代器读取并结束占位符
+	f.iter.skip(end)
+	return block.render(f.exprFormatter)
+}
+
 func (f *format) format() string {
-	index := -1
+	var indices []int
+	var indexErr error // 索引不正确的占位符不输出，例如{0,}
 	var err error
 	for {
 		state, token, err := f.iter.NextToken()
@@ -58,32 +170,42 @@ func (f *format) format() string {
 		case FORMAT_STATE_LITERAL:
 			f.sb.WriteString(token)
 		case FORMAT_STATE_PARSE_INDEX:
-			index, err = strconv.Atoi(token)
-			if err != nil {
-				break
-			}
+			indices, indexErr = parseIndices(token)
 		case FORMAT_STATE_PARSE_FORMATTER:
-			getFmt, ok := env.valFormatters[token[0]]
-			if !ok {
+			// 没有注册的标签或名字、命名格式化器的实参不正确时原样输出参数
+			f.formatter, f.name, _ = newFormatter(token)
+			if f.formatter == nil {
 				f.formatter = &DefaultFormatter{}
 			}
-			f.formatter = getFmt()
-			f.formatter.Parse(token[1:])
-			f.formatValue(index)
+			if f.name != "" {
+				f.spec = strings.TrimPrefix(token, f.name)
+			} else if token != "" {
+				f.label, f.spec = token[0], token[1:]
+			}
+		case FORMAT_STATE_PARSE_FILTER:
+			f.filters = token
 		case FORMAT_STATE_EXPR:
 			var str string
-			str, err = f.exprFormatter.Eval(token)
+			if _, isBlock, _ := f.exprFormatter.parseBlockTag(token); isBlock {
+				str, err = f.formatBlock(token)
+			} else {
+				str, err = f.exprFormatter.Eval(token)
+			}
 			if err != nil {
+				f.exprErr = errors.Join(f.exprErr, err)
 				break
 			}
 			f.sb.WriteString(str)
 		case FORMAT_STATE_PLACEHOLDER_END:
-			index = -1
+			indices, indexErr = nil, nil
 			f.formatter = nil
+			f.label, f.name, f.spec = 0, "", ""
+			f.filters = ""
 		}
 
-		if state == FORMAT_STATE_PLACEHOLDER_END && (f.lastState == FORMAT_STATE_PLACEHOLDER_START || f.lastState == FORMAT_STATE_PARSE_INDEX) {
-			f.formatValue(index)
+		if state == FORMAT_STATE_PLACEHOLDER_END && indexErr == nil && (f.lastState == FORMAT_STATE_PLACEHOLDER_START || f.lastState == FORMAT_STATE_PARSE_INDEX ||
+			f.lastState == FORMAT_STATE_PARSE_FORMATTER || f.lastState == FORMAT_STATE_PARSE_FILTER) {
+			f.formatValue(indices)
 		}
 
 		f.lastState = state
@@ -117,7 +239,27 @@ func (f *format) format() string {
 //Lang是表达式解析器所在的命名空间，后面紧跟两个冒号，然后是系统变量或函数的名字，可以包含字母、数字、下划线和.
 //如果你将Lang设置为默认解析器，那么你可以省略Lang::，直接写变量/函数的名字，例如将Lang::hello改为hello
 //可以使用$0、$1、$2等来引用格式化参数，$0表示第一个参数，$1表示第二个参数，以此类推
-//字符串常量需要使用单引号包裹
+//字符串常量需要使用单引号或双引号包裹，可以使用\' \\ \n \t \uXXXX转义，也可以重复引号：'don''t'，数字是数值常量，true和false是布尔常量
+//表达式的值保持原来的类型，只在写入结果时转换为字符串：{{$0 * 2 + 1}}对整数参数做算术运算，
+//+对数值相加、对其他值连接字符串，内置的- * / %只用于数值，见RegisterOperatorFunc
+//表达式可以使用括号，操作符按注册时声明的优先级和结合性计算，见RegisterOperator和RegisterUnaryOperator
+//在索引或格式化器后面跟|<过滤器>对结果进行变换，表达式中同样可以使用过滤器，见RegisterFilter：
+//Fmt("{0|upper|trunc(3)}", "hello") => "HEL"，Fmt("{0:%.2f|replace('.', ',')}", 3.14159) => "3,14"
+//Fmt("{{ Lang::title | lower }}")
+//{{> footer}}插入用Define定义的模板并传入当前的参数，{{tpl::footer($1, 'x')}}或{{> footer($1, 'x')}}传入指定的参数
+//实现了IMultiArgFormatter的格式化器使用多个参数，可以写多个索引：Fmt("{0,1:range}", 3, 5) => "3–5"，
+//也可以按顺序使用：Fmt("[{:%*d}]", 4, 7) => "[   7]"
+//格式化器也可以用名字指定并传入实参，见RegisterNamedFormatter：注册money后Fmt("{0:money(USD, 2)}", 3.5) => "$3.50"
+//{0:each(', ', ' and ')}连接切片、数组、map或iter.Seq参数的元素，块形式对每个元素渲染其中的内容，$item为元素，
+//$index为序号，$key为下标或map的键，可以指定分隔符和最后一个分隔符，{{else}}之后是没有元素时的内容：
+//Fmt("{{#each $0, ', ', ' and '}}{{$index + 1}}. {{$item}}{{else}}none{{/each}}", items)
+//{0:&}按CLDR列表模式连接元素，可以指定语言、类型（and、or、unit）和宽度（short、narrow），默认语言见SetLocale：
+//Fmt("{0:&} / {0:&zh} / {0:&fr,or}", []string{"a", "b", "c"}) => "a, b, and c / a、b和c / a, b ou c"，表达式中使用list过滤器
+//{0:^}输出序数，{0:#roman}、{0:#hans}、{0:#fullwide}等使用其他数字系统，两者都可以加上group选项按3位分组：
+//Fmt("{0:^} / {0:^zh} / {1:#hansfin} / {1:#arab,group}", 2, 1234) => "2nd / 第2 / 壹仟贰佰叁拾肆 / ١٬٢٣٤"
+//{{a ?? b}}在a不存在时使用b，见SetMissingKeyPolicy
+//比较操作符== != < <= > >=，逻辑操作符&& || !和条件表达式cond ? a : b按Value.Truthy判断真假，
+//未选中的分支不会求值：Fmt("{{ $0 == 0 ? 'no messages' : count($0) }}", n)
 func Fmt(pattern string, args ...any) string {
 	format := &format{
 		args: args,
@@ -128,4 +270,15 @@ func Fmt(pattern string, args ...any) string {
 	format.exprFormatter.Args = args
 
 	return format.format()
+}
+
+//render 使用已有的求值环境渲染格式化字符串，同时返回求值失败的表达式的错误
+func render(pattern string, exprFormatter *ExprFormatter) (string, error) {
+	format := &format{
+		args: exprFormatter.Args,
+		iter: NewFormatIter(pattern),
+		exprFormatter: exprFormatter,
+	}
+	str := format.format()
+	return str, format.exprErr
 }
\ No newline at end of file
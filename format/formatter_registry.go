@@ -0,0 +1,276 @@
+package format
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+//FormatterParam 命名格式化器的参数声明
+type FormatterParam struct {
+	Name     string    // 参数名，ParseArgs中按名字读取
+	Kind     ValueKind // 参数的类型，VALUE_ANY接受任意常量；裸单词（例如USD）是字符串
+	Optional bool      // 是否可以省略，省略时为Default
+	Variadic bool      // 只能是最后一个参数，接受剩余的所有实参，值为列表
+	Default  Value
+	Doc      string
+}
+
+//FormatterInfo 已注册的格式化器
+type FormatterInfo struct {
+	Name   string           // 格式化器的名字，以单字符标签注册时为空
+	Label  byte             // 单字符标签，以名字注册时为0
+	Params []FormatterParam // 命名格式化器的参数声明，单字符标签的格式化器自行解析标签后面的内容
+}
+
+//FormatterArgs 按参数声明解析的实参，键为参数名，省略的可选参数为其Default
+type FormatterArgs map[string]Value
+
+//IArgsFormatter 接受结构化实参的格式化器。以名字注册的格式化器实现该接口时调用ParseArgs，
+//否则将实参按参数声明的顺序转换为字符串、用逗号连接后调用Parse，例如list(fr, or)调用Parse("fr,or")
+type IArgsFormatter interface {
+	IValueFormatter
+	ParseArgs(args FormatterArgs) error
+}
+
+type namedFormatter struct {
+	info         FormatterInfo
+	getFormatter func() IValueFormatter
+}
+
+//RegisterNamedFormatter 以名字注册格式化器，替换同名的格式化器，例如注册money后可以使用{0:money(USD, 2)}
+//@params name 名字，可以包含字母、数字、下划线和.; params 参数声明，实参按位置对应
+func RegisterNamedFormatter(name string, getFormatter func() IValueFormatter, params ...FormatterParam) error {
+	if !isLabel(name) {
+		return fmt.Errorf("invalid formatter name: %q", name)
+	}
+	optional := false
+	for i, param := range params {
+		if param.Variadic && i != len(params)-1 {
+			return fmt.Errorf("formatter %s: variadic param %s must be the last", name, param.Name)
+		}
+		if optional && !param.Optional && !param.Variadic {
+			return fmt.Errorf("formatter %s: required param %s follows an optional one", name, param.Name)
+		}
+		optional = optional || param.Optional
+	}
+	env.namedFormatters[name] = &namedFormatter{
+		info:         FormatterInfo{Name: name, Params: params},
+		getFormatter: getFormatter,
+	}
+	return nil
+}
+
+//LookupFormatter 返回以名字注册的格式化器
+func LookupFormatter(name string) (FormatterInfo, bool) {
+	if named, ok := env.namedFormatters[name]; ok {
+		return named.info, true
+	}
+	return FormatterInfo{}, false
+}
+
+//Formatters 返回所有已注册的格式化器，单字符标签按标签排序在前，命名格式化器按名字排序在后
+func Formatters() []FormatterInfo {
+	var infos []FormatterInfo
+	for label := range env.valFormatters {
+		infos = append(infos, FormatterInfo{Label: label})
+	}
+	for _, named := range env.namedFormatters {
+		infos = append(infos, named.info)
+	}
+	slices.SortFunc(infos, func(a, b FormatterInfo) int {
+		if a.Name != b.Name {
+			return strings.Compare(a.Name, b.Name)
+		}
+		return int(a.Label) - int(b.Label)
+	})
+	return infos
+}
+
+//splitFormatterName 拆分name或name(args...)形式的格式化器，args为括号中的内容
+func splitFormatterName(spec string) (name string, args string, ok bool) {
+	end := 0
+	for end < len(spec) {
+		ch, size := utf8.DecodeRuneInString(spec[end:])
+		if !isLabelChar(ch) || (end == 0 && !isLabelStart(ch)) {
+			break
+		}
+		end += size
+	}
+	if end == 0 {
+		return "", "", false
+	}
+	rest := spec[end:]
+	if rest == "" {
+		return spec, "", true
+	}
+	if rest[0] != '(' || rest[len(rest)-1] != ')' {
+		return "", "", false
+	}
+	return spec[:end], rest[1 : len(rest)-1], true
+}
+
+//constArg 格式化器的实参只能是常量：字符串、数值、布尔值，以及作为字符串的裸单词
+func constArg(ex Expr) (Value, bool) {
+	switch arg := ex.(type) {
+	case *tokenLiteral, *tokenNumber, *tokenBool:
+		value, err := ex.Eval(nil)
+		return value, err == nil
+	case *tokenVar:
+		if arg.namespace == "" {
+			return StringValue(string(arg.key)), true
+		}
+	case *unaryExpr:
+		// 负数，例如-1
+		if _, ok := arg.operand.(*tokenNumber); ok {
+			value, err := ex.Eval(NewExprFormatter(env.exprFormatterConfig))
+			return value, err == nil
+		}
+	}
+	return Nil, false
+}
+
+//parseFormatterArgs 解析括号中逗号分隔的实参
+func parseFormatterArgs(args string) ([]Value, error) {
+	var values []Value
+	if strings.TrimSpace(args) == "" {
+		return values, nil
+	}
+	p := env.exprFormatterConfig.NewParser(args)
+	for {
+		start := p.pos
+		param, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		value, ok := constArg(param)
+		if !ok {
+			p.pos = start
+			return nil, p.errorf("formatter arg must be a constant: %s", strings.TrimSpace(args[start:]))
+		}
+		values = append(values, value)
+		p.skipSpace()
+		if ok, _ := p.Require(isChar(',')); !ok {
+			break
+		}
+		p.pos++
+	}
+	if p.pos < len(p.expr) {
+		return nil, p.errorf("unexpected %q", p.residue())
+	}
+	return values, nil
+}
+
+//convertFormatterArg 将实参转换为参数声明的类型
+func convertFormatterArg(param FormatterParam, value Value) (Value, error) {
+	switch param.Kind {
+	case VALUE_ANY, value.Kind():
+		return value, nil
+	case VALUE_STRING:
+		return StringValue(value.String()), nil
+	case VALUE_NUMBER:
+		// 带引号的数字，例如'2'
+		if n, ok := parseNumber(strings.TrimSpace(value.String())); ok {
+			return NumberValue(n.value), nil
+		}
+	}
+	return Nil, fmt.Errorf("arg %s must be a %s, got %q", param.Name, param.Kind, value.String())
+}
+
+//bindFormatterArgs 按参数声明将实参对应到参数名
+func bindFormatterArgs(info FormatterInfo, values []Value) (FormatterArgs, error) {
+	args := make(FormatterArgs)
+	for i, param := range info.Params {
+		if param.Variadic {
+			var rest []Value
+			for _, value := range values[min(i, len(values)):] {
+				value, err := convertFormatterArg(param, value)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", info.Name, err)
+				}
+				rest = append(rest, value)
+			}
+			args[param.Name] = ListValue(rest)
+			return args, nil
+		}
+		if i >= len(values) {
+			if !param.Optional {
+				return nil, fmt.Errorf("%s: missing arg %s", info.Name, param.Name)
+			}
+			args[param.Name] = param.Default
+			continue
+		}
+		value, err := convertFormatterArg(param, values[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", info.Name, err)
+		}
+		args[param.Name] = value
+	}
+	if len(values) > len(info.Params) {
+		return nil, fmt.Errorf("%s: expected at most %d args, got %d", info.Name, len(info.Params), len(values))
+	}
+	return args, nil
+}
+
+//joinFormatterArgs 按参数声明的顺序将实参转换为字符串并用逗号连接，省略的参数使用Default，为nil时跳过
+func joinFormatterArgs(info FormatterInfo, args FormatterArgs) string {
+	var strs []string
+	for _, param := range info.Params {
+		value := args[param.Name]
+		if param.Variadic {
+			for _, item := range value.List() {
+				strs = append(strs, item.String())
+			}
+		} else if !value.IsNil() {
+			strs = append(strs, value.String())
+		}
+	}
+	return strings.Join(strs, ",")
+}
+
+//newFormatter 按格式化器的名字或单字符标签创建格式化器并解析参数，name为命名格式化器的名字。
+//单字符标签的格式化器解析失败时同时返回格式化器和错误，与Fmt原来的行为一致
+func newFormatter(spec string) (formatter IValueFormatter, name string, err error) {
+	if name, args, ok := splitFormatterName(spec); ok {
+		if named, ok := env.namedFormatters[name]; ok {
+			values, err := parseFormatterArgs(args)
+			if err != nil {
+				return nil, name, fmt.Errorf("%s: %w", name, err)
+			}
+			bound, err := bindFormatterArgs(named.info, values)
+			if err != nil {
+				return nil, name, err
+			}
+			formatter = named.getFormatter()
+			if af, ok := formatter.(IArgsFormatter); ok {
+				err = af.ParseArgs(bound)
+			} else {
+				err = formatter.Parse(joinFormatterArgs(named.info, bound))
+			}
+			if err != nil {
+				return nil, name, fmt.Errorf("%s: %w", name, err)
+			}
+			return formatter, name, nil
+		}
+	}
+	if spec == "" {
+		return nil, "", InvalidFormatterError{Formatter: spec}
+	}
+	getFormatter, ok := env.valFormatters[spec[0]]
+	if !ok {
+		return nil, "", InvalidFormatterError{Formatter: spec}
+	}
+	formatter = getFormatter()
+	return formatter, "", formatter.Parse(spec[1:])
+}
+
+//NewFormatter 创建占位符中':'后面的格式化器，例如money(USD, 2)、%.2f，
+//名字或标签没有注册时返回InvalidFormatterError，实参不符合参数声明或格式不正确时返回错误
+func NewFormatter(spec string) (IValueFormatter, error) {
+	formatter, _, err := newFormatter(spec)
+	if err != nil {
+		return nil, err
+	}
+	return formatter, nil
+}
@@ -0,0 +1,150 @@
+package format
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+//FuncInterpreter 调用已注册Go函数的表达式解释器，{{add($0, $1)}}会调用注册为add的函数
+//实参会转换为函数的参数类型：可以直接赋值或数值之间转换，字符串与数值、布尔值之间通过strconv转换；
+//支持可变参数。没有实参的引用，例如{{now}}，会以零个实参调用函数
+type FuncInterpreter struct {
+	mu    sync.RWMutex
+	funcs map[string]reflect.Value
+}
+
+func NewFuncInterpreter() *FuncInterpreter {
+	return &FuncInterpreter{funcs: make(map[string]reflect.Value)}
+}
+
+//Register 注册函数，函数返回一个值，或者一个值和一个error，返回值保持原来的类型参与表达式的运算
+func (i *FuncInterpreter) Register(name string, fn any) error {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return fmt.Errorf("%s is not a function", name)
+	}
+	t := v.Type()
+	if t.NumOut() == 0 || t.NumOut() > 2 || (t.NumOut() == 2 && t.Out(1) != errorType) {
+		return fmt.Errorf("%s must return a value, or a value and an error", name)
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.funcs[name] = v
+	return nil
+}
+
+//MustRegister 与Register相同，出错时panic，返回解释器本身以便链式注册
+func (i *FuncInterpreter) MustRegister(name string, fn any) *FuncInterpreter {
+	if err := i.Register(name, fn); err != nil {
+		panic(err)
+	}
+	return i
+}
+
+func (i *FuncInterpreter) Format(key string, args []any) (string, error) {
+	value, err := i.Eval(key, args)
+	return value.String(), err
+}
+
+func (i *FuncInterpreter) Eval(key string, args []any) (Value, error) {
+	i.mu.RLock()
+	fn, ok := i.funcs[key]
+	i.mu.RUnlock()
+	if !ok {
+		return Nil, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+	t := fn.Type()
+	fixed := t.NumIn()
+	if t.IsVariadic() {
+		fixed--
+		if len(args) < fixed {
+			return Nil, fmt.Errorf("%s: expected at least %d args, got %d", key, fixed, len(args))
+		}
+	} else if len(args) != fixed {
+		return Nil, fmt.Errorf("%s: expected %d args, got %d", key, fixed, len(args))
+	}
+	in := make([]reflect.Value, len(args))
+	for n, arg := range args {
+		var paramType reflect.Type
+		if n < fixed {
+			paramType = t.In(n)
+		} else {
+			paramType = t.In(fixed).Elem()
+		}
+		v, err := convertArg(arg, paramType)
+		if err != nil {
+			return Nil, fmt.Errorf("%s: arg %d: %w", key, n, err)
+		}
+		in[n] = v
+	}
+	out := fn.Call(in)
+	if len(out) == 2 && !out[1].IsNil() {
+		return Nil, out[1].Interface().(error)
+	}
+	return ToValue(out[0].Interface()), nil
+}
+
+func isNumber(kind reflect.Kind) bool {
+	return (kind >= reflect.Int && kind <= reflect.Uint64) || kind == reflect.Float32 || kind == reflect.Float64
+}
+
+//convertArg 将实参转换为参数类型
+func convertArg(arg any, t reflect.Type) (reflect.Value, error) {
+	if arg == nil {
+		return reflect.Zero(t), nil
+	}
+	v := reflect.ValueOf(arg)
+	if v.Type().AssignableTo(t) {
+		return v, nil
+	}
+	switch {
+	case isNumber(v.Kind()) && isNumber(t.Kind()):
+		return v.Convert(t), nil
+	case t.Kind() == reflect.String:
+		// 避免整数被当作码点转换为字符
+		return reflect.ValueOf(fmt.Sprint(arg)).Convert(t), nil
+	case v.Kind() == reflect.String:
+		return parseArg(v.String(), t)
+	case v.Type().ConvertibleTo(t) && v.Kind() == t.Kind():
+		return v.Convert(t), nil
+	}
+	return reflect.Value{}, fmt.Errorf("can not convert %T to %s", arg, t)
+}
+
+//parseArg 将字符串实参解析为数值或布尔值参数
+func parseArg(s string, t reflect.Type) (reflect.Value, error) {
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, t.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, t.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, t.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return v, err
+		}
+		v.SetBool(b)
+	default:
+		return v, fmt.Errorf("can not convert string to %s", t)
+	}
+	return v, nil
+}
@@ -2,6 +2,18 @@ package format
 
 import "errors"
 
+var (
+	//ErrKeyNotFound 解释器中没有表达式引用的变量或函数，解释器应该返回包装了它的错误
+	ErrKeyNotFound = errors.New("key not found")
+	//ErrNamespaceNotFound 表达式引用的命名空间没有注册解释器
+	ErrNamespaceNotFound = errors.New("namespace not found")
+)
+
+//IsNotFound 判断错误是否表示变量、函数或命名空间不存在
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrKeyNotFound) || errors.Is(err, ErrNamespaceNotFound)
+}
+
 type IterEndError struct {
 }
 
@@ -26,7 +38,7 @@ func IsIterEnd(err error) bool {
 }
 
 type IFormatIter interface {
-	Next() (FormatState, byte, error)        // 读取下一个字符并返回迭代器当前状态，读取到字符串结尾时返回IterEndError
+	Next() (FormatState, rune, error)        // 读取下一个字符（按UTF-8解码）并返回迭代器当前状态，读取到字符串结尾时返回IterEndError
 	NextToken() (FormatState, string, error) // 读取字符直到迭代器状态产生变化，返回迭代器当前状态，读取到字符串结尾时返回IterEndError
 	GetState() FormatState                   // 获取迭代器状态
 }
@@ -39,10 +51,39 @@ type IValueFormatter interface {
 	Format(value any) string
 }
 
+//IMultiArgFormatter 使用多个参数的格式化器，可以选择实现。占位符按ArgCount绑定参数：
+//{0,1:range}使用第0和第1个参数，{2:range}使用从第2个开始的ArgCount个参数，{:%*d}使用接下来的ArgCount个参数，
+//省略索引时下一个占位符从这些参数之后开始
+type IMultiArgFormatter interface {
+	IValueFormatter
+	//ArgCount 使用的参数个数，在Parse之后调用
+	ArgCount() int
+	//FormatArgs 格式化绑定的参数，len(args)为ArgCount()
+	FormatArgs(args []any) string
+}
+
 //IExprInterpreter 表达式解释器接口
 type IExprInterpreter interface {
 	//Format 将传入的表达式（变量和函数）求值，返回字符串
 	//@params key 变量名或函数名，例如{{name}}（传入的key为name）或{{fn($0)}}（传入的key为fn）
 	//@params args 传入的实参
+	//@return 没有该变量或函数时，返回包装了ErrKeyNotFound的错误，例如fmt.Errorf("%w: %s", ErrKeyNotFound, key)
 	Format(key string, args []any) (string, error)
 }
+
+//IValueInterpreter 返回带类型的值的表达式解释器，可以选择实现。
+//结果在表达式中保持原来的类型参与运算，例如{{count + 1}}，只在写入输出时转换为字符串
+type IValueInterpreter interface {
+	IExprInterpreter
+	//Eval 求值，参数和错误与IExprInterpreter.Format相同
+	Eval(key string, args []any) (Value, error)
+}
+
+//IPatternInterpreter 值为格式化字符串的表达式解释器，可以选择实现，例如MapInterpreter。
+//表达式引用的格式化字符串与模板一样在当前的求值环境中使用实参渲染，嵌套引用受MaxIncludeDepth限制并检测循环，
+//Format可以使用FormatPattern实现
+type IPatternInterpreter interface {
+	IExprInterpreter
+	//Pattern 返回键对应的格式化字符串，没有该键时返回包装了ErrKeyNotFound的错误
+	Pattern(key string) (string, error)
+}
@@ -3,6 +3,7 @@ package format
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 )
 
 type FormatState int
@@ -18,6 +19,7 @@ const (
 	FORMAT_STATE_EXPR_END                      // 解析到}}表达式终止
 	FORMAT_STATE_ERROR                         // 解析错误
 	FORMAT_STATE_END
+	FORMAT_STATE_PARSE_FILTER // '|'后跟着过滤器链
 )
 
 func (s FormatState) String() string {
@@ -42,12 +44,14 @@ func (s FormatState) String() string {
 		return "FORMAT_STATE_EXPR"
 	case FORMAT_STATE_EXPR_END:
 		return "FORMAT_STATE_EXPR_END"
+	case FORMAT_STATE_PARSE_FILTER:
+		return "FORMAT_STATE_PARSE_FILTER"
 	default:
 		return "UNKNOWN_FORMAT_STATE"
 	}
 }
 
-func (s FormatState) startStateNext(ch byte, pos *int) FormatState {
+func (s FormatState) startStateNext(ch rune, pos *int) FormatState {
 	if ch == '{' { // 如果遇到'{'字符，进入占位符解析状态
 		(*pos)++
 		return FORMAT_STATE_PLACEHOLDER_START
@@ -56,7 +60,7 @@ func (s FormatState) startStateNext(ch byte, pos *int) FormatState {
 	return FORMAT_STATE_LITERAL
 }
 
-func (s FormatState) literalStateNext(ch byte, pos *int) FormatState {
+func (s FormatState) literalStateNext(ch rune, pos *int) FormatState {
 	(*pos)++
 	if ch == '{' { // 如果遇到'{'字符，进入占位符解析状态
 		return FORMAT_STATE_PLACEHOLDER_START
@@ -65,8 +69,8 @@ func (s FormatState) literalStateNext(ch byte, pos *int) FormatState {
 	return FORMAT_STATE_LITERAL
 }
 
-func (s FormatState) placeholderStartStateNext(ch byte, pos *int) FormatState {
-	//fmt.Println("placeholderStartStateNext ", string([]byte{ch}))
+func (s FormatState) placeholderStartStateNext(ch rune, pos *int) FormatState {
+	//fmt.Println("placeholderStartStateNext ", string(ch))
 	if ch >= '0' && ch <= '9' { // 如果遇到数字字符，进入参数索引解析状态
 		return FORMAT_STATE_PARSE_INDEX
 	} else if ch == ':' { // 如果遇到':'字符，进入格式化器解析状态
@@ -78,12 +82,15 @@ func (s FormatState) placeholderStartStateNext(ch byte, pos *int) FormatState {
 	} else if ch == '{' { // 双层{表示表达式
 		(*pos)++
 		return FORMAT_STATE_EXPR
+	} else if ch == '|' { // 如果遇到'|'字符，进入过滤器解析状态
+		(*pos)++
+		return FORMAT_STATE_PARSE_FILTER
 	}
 	// 否则返回错误状态
 	return FORMAT_STATE_ERROR
 }
 
-func (s FormatState) exprStateNext(ch byte, pos *int) FormatState {
+func (s FormatState) exprStateNext(ch rune, pos *int) FormatState {
 	(*pos)++
 	if ch == '}' {
 		return FORMAT_STATE_EXPR_END
@@ -91,7 +98,7 @@ func (s FormatState) exprStateNext(ch byte, pos *int) FormatState {
 	return FORMAT_STATE_EXPR
 }
 
-func (s FormatState) exprStateEndNext(ch byte, pos *int) FormatState {
+func (s FormatState) exprStateEndNext(ch rune, pos *int) FormatState {
 	if ch == '}' {
 		(*pos)++
 		return FORMAT_STATE_PLACEHOLDER_END
@@ -99,33 +106,49 @@ func (s FormatState) exprStateEndNext(ch byte, pos *int) FormatState {
 	return FORMAT_STATE_ERROR
 }
 
-func (s FormatState) parseIndexStateNext(ch byte, pos *int) FormatState {
+func (s FormatState) parseIndexStateNext(ch rune, pos *int) FormatState {
 	(*pos)++
 	if ch >= '0' && ch <= '9' { // 如果遇到数字字符，继续保持参数索引解析状态
 		return FORMAT_STATE_PARSE_INDEX
+	} else if ch == ',' { // 多个参数索引用','分隔，例如{0,1:range}
+		return FORMAT_STATE_PARSE_INDEX
 	} else if ch == ':' { // 如果遇到':'字符，进入格式化器解析状态
 		return FORMAT_STATE_PARSE_FORMATTER
 	} else if ch == '}' { // 如果遇到'}'字符，进入占位符结束状态
 		return FORMAT_STATE_PLACEHOLDER_END
+	} else if ch == '|' { // 如果遇到'|'字符，进入过滤器解析状态
+		return FORMAT_STATE_PARSE_FILTER
 	}
 	// 否则返回错误状态
 	return FORMAT_STATE_END
 }
 
-func (s FormatState) parseFormatterStateNext(ch byte, pos *int) FormatState {
+func (s FormatState) parseFormatterStateNext(ch rune, pos *int) FormatState {
 	(*pos)++
 	if ch == '}' { // 如果遇到'}'字符，进入占位符结束状态
 		return FORMAT_STATE_PLACEHOLDER_END
+	} else if ch == '|' { // 格式化器后面跟着过滤器链，格式化的结果作为过滤器的输入
+		return FORMAT_STATE_PARSE_FILTER
 	}
 	// 否则继续保持格式化器解析状态
 	return FORMAT_STATE_PARSE_FORMATTER
 }
 
-func (s FormatState) placeholderEndStateNext(ch byte, pos *int) FormatState {
+func (s FormatState) parseFilterStateNext(ch rune, pos *int) FormatState {
+	(*pos)++
+	if ch == '}' { // 如果遇到'}'字符，进入占位符结束状态
+		return FORMAT_STATE_PLACEHOLDER_END
+	}
+	// 否则继续保持过滤器解析状态
+	return FORMAT_STATE_PARSE_FILTER
+}
+
+func (s FormatState) placeholderEndStateNext(ch rune, pos *int) FormatState {
 	return FORMAT_STATE_START
 }
 
-func (s FormatState) Next(ch byte, pos *int) FormatState {
+//Next 根据读取到的字符返回下一个状态，状态消耗了该字符时pos加1，否则该字符会在下一个状态中再次读取
+func (s FormatState) Next(ch rune, pos *int) FormatState {
 	if ch == 0 {
 		return FORMAT_STATE_END
 	}
@@ -146,53 +169,109 @@ func (s FormatState) Next(ch byte, pos *int) FormatState {
 		return s.exprStateNext(ch, pos)
 	case FORMAT_STATE_EXPR_END:
 		return s.exprStateEndNext(ch, pos)
+	case FORMAT_STATE_PARSE_FILTER:
+		return s.parseFilterStateNext(ch, pos)
 	default:
 		return FORMAT_STATE_ERROR
 	}
 }
 
+//FormatIter 格式化字符串的迭代器，按UTF-8解码字符
 type FormatIter struct {
-	input []byte
-	pos   int
-	state FormatState
+	input  []byte
+	pos    int
+	column int // 已读取的字符（rune）数
+	state  FormatState
+	parens int  // 格式化器或过滤器中未闭合的'('数
+	quote  rune // 表达式中或格式化器、过滤器的实参中未闭合的引号，引号中的'|'和'}'不改变状态
+	escape bool // 引号中上一个字符是'\'
 }
 
 func NewFormatIter(input string) *FormatIter {
 	return &FormatIter{input: []byte(input), pos: 0, state: FORMAT_STATE_START}
 }
 
-func (i *FormatIter) Next() (FormatState, byte, error) {
-	//fmt.Println("pos: ", i.pos)
-	//fmt.Printf("state: %s, pos: %d\n", i.state.String(), i.pos)
+func (i *FormatIter) Next() (FormatState, rune, error) {
 	if i.pos >= len(i.input) {
 		return FORMAT_STATE_END, 0, IterEndError{}
 	}
-	ch := i.input[i.pos]
-	i.state = i.state.Next(ch, &i.pos)
+	// 不合法的UTF-8字节解码为utf8.RuneError，NextToken仍然输出原始的字节
+	ch, size := utf8.DecodeRune(i.input[i.pos:])
+	if i.quote != 0 {
+		// 引号中的字符不改变状态，重复的引号（例如'don''t'）相当于先闭合再打开
+		switch {
+		case i.escape:
+			i.escape = false
+		case ch == '\\':
+			i.escape = true
+		case ch == i.quote:
+			i.quote = 0
+		}
+		i.pos += size
+		i.column++
+		return i.state, ch, nil
+	}
+	step := 0
+	state := i.state.Next(ch, &step)
+	if state != i.state {
+		i.parens = 0
+	}
+	i.state = state
+	i.pos += step * size
+	i.column += step
+	if step == 0 {
+		return i.state, ch, nil
+	}
+	switch state {
+	case FORMAT_STATE_EXPR:
+		// 表达式中的字符串常量，例如{{ 'x' | replace('x', '}') }}
+		if ch == '\'' || ch == '"' {
+			i.quote = ch
+		}
+	case FORMAT_STATE_PARSE_FORMATTER, FORMAT_STATE_PARSE_FILTER:
+		// 只有括号中的实参可以是字符串，例如{0:each(' | ')}、{0|replace('|', '/')}，{0:@15:04 o'clock}中的'是字面量
+		switch {
+		case ch == '(':
+			i.parens++
+		case ch == ')' && i.parens > 0:
+			i.parens--
+		case (ch == '\'' || ch == '"') && i.parens > 0:
+			i.quote = ch
+		}
+	}
 	return i.state, ch, nil
 }
 
 func (i *FormatIter) NextToken() (FormatState, string, error) {
-	//fmt.Println("NextToken")
 	var sb strings.Builder
 	originState := i.state
 	for {
-		state, ch, err := i.Next()
-		//fmt.Printf("pos: %d, ch: %c\n", i.pos, ch)
+		start := i.pos
+		state, _, err := i.Next()
 		if err != nil { // 如果读取到字符串结尾，返回迭代器当前状态和读取到的字符串
 			return state, sb.String(), err
 		}
 		if state == FORMAT_STATE_ERROR { // 如果读取到错误状态，返回错误
-			return state, sb.String(), fmt.Errorf("Invalid format string: %s", sb.String())
+			return state, sb.String(), fmt.Errorf("Invalid format string at column %d: %s", i.Column(), sb.String())
 		}
 		if state != originState { // 如果迭代器状态产生变化，返回迭代器当前状态和读取到的字符串
-			//fmt.Printf("token: [%s], next state: [%s]\n", sb.String(), state.String())
 			return state, sb.String(), nil
 		}
-		sb.WriteByte(ch)
+		sb.Write(i.input[start:i.pos])
 	}
 }
 
 func (i *FormatIter) GetState() FormatState {
 	return i.state
 }
+
+//skip 跳过n个字节
+func (i *FormatIter) skip(n int) {
+	i.column += utf8.RuneCount(i.input[i.pos : i.pos+n])
+	i.pos += n
+}
+
+//Column 返回下一个字符从1开始的列号，按字符（rune）计数
+func (i *FormatIter) Column() int {
+	return i.column + 1
+}
@@ -0,0 +1,62 @@
+package format
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFmtQuotedArgs(t *testing.T) {
+	tests := []struct {
+		pattern string
+		args    []any
+		want    string
+	}{
+		{"{0:each(' | ')}", []any{[]string{"a", "b"}}, "a | b"},
+		{"{0|replace('|', '/')}", []any{"a|b"}, "a/b"},
+		{"{0:each(' | ')|upper}!", []any{[]string{"a", "b"}}, "A | B!"},
+		{"{0:each('}')}!", []any{[]string{"a", "b"}}, "a}b!"},
+		{"{0:each('don''t')}", []any{[]string{"a", "b"}}, "adon'tb"},
+		{"{0:@15:04 o'clock} {1}", []any{time.Date(2020, 1, 1, 3, 4, 0, 0, time.UTC), 2}, "03:04 o'clock 2"},
+	}
+	for _, test := range tests {
+		if got := Fmt(test.pattern, test.args...); got != test.want {
+			t.Errorf("Fmt(%q) = %q, want %q", test.pattern, got, test.want)
+		}
+	}
+}
+
+func TestFmtQuotedExpr(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"{{ 'a}}b' }}", "a}}b"},
+		{"{{ 'a}b' }}", "a}b"},
+		{`{{ "a}}" }}!`, "a}}!"},
+		{`{{ 'it\'s}' }}`, "it's}"},
+		{"{{ 'don''t}' }}", "don't}"},
+		{"{{ 'x' | replace('x', '}') }}", "}"},
+		{"{{ 'a|b' | replace('|', '/') }}", "a/b"},
+	}
+	for _, test := range tests {
+		if got := Fmt(test.pattern); got != test.want {
+			t.Errorf("Fmt(%q) = %q, want %q", test.pattern, got, test.want)
+		}
+	}
+}
+
+func TestPlaceholdersQuotedArgs(t *testing.T) {
+	placeholders, err := Placeholders("{0:each(' | ')} {0|replace('|', '/')}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(placeholders) != 2 {
+		t.Fatalf("got %d placeholders, want 2", len(placeholders))
+	}
+	if p := placeholders[0]; p.Formatter != "each(' | ')" || p.Filters != "" {
+		t.Errorf("placeholder 0: formatter %q, filters %q", p.Formatter, p.Filters)
+	}
+	if p := placeholders[1]; p.HasFormatter || p.Filters != "replace('|', '/')" {
+		t.Errorf("placeholder 1: formatter %q, filters %q", p.Formatter, p.Filters)
+	}
+}
@@ -0,0 +1,87 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Khellendros97/khutils/list"
+)
+
+//LIST_FORMATTER_LABEL 按CLDR列表模式连接元素的格式化器
+//{0:&}使用默认语言，{0:&fr}、{0:&or}、{0:&zh,unit,narrow}指定语言、类型和宽度，顺序任意
+//也可以使用名字：{0:list(fr, or)}
+const (
+	LIST_FORMATTER_LABEL = '&'
+	LIST_FORMATTER_NAME  = "list"
+)
+
+type ListFormatter struct {
+	locale    string
+	listType  list.Type
+	listWidth list.Width
+}
+
+func NewListFormatter() IValueFormatter {
+	return &ListFormatter{}
+}
+
+//isLocale 是否是语言标签，例如en、zh-Hant-TW
+func isLocale(s string) bool {
+	for i, ch := range s {
+		if !(ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || i > 0 && (ch >= '0' && ch <= '9' || ch == '-' || ch == '_')) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+//parseListOptions 解析逗号分隔的选项，既不是类型也不是宽度的选项作为语言
+func parseListOptions(options []string) (locale string, t list.Type, w list.Width, err error) {
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			continue
+		}
+		if v, ok := list.ParseType(option); ok {
+			t = v
+		} else if v, ok := list.ParseWidth(option); ok {
+			w = v
+		} else if isLocale(option) && locale == "" {
+			locale = option
+		} else {
+			return "", "", "", fmt.Errorf("invalid list option: %q", option)
+		}
+	}
+	return
+}
+
+func (f *ListFormatter) Parse(token string) (err error) {
+	f.locale, f.listType, f.listWidth, err = parseListOptions(strings.Split(token, ","))
+	return
+}
+
+//listItems 将可遍历的值的元素转换为字符串
+func listItems(value any) ([]string, error) {
+	var items []string
+	err := iterate(value, func(_ any, item any) bool {
+		items = append(items, fmt.Sprintf("%v", item))
+		return true
+	})
+	return items, err
+}
+
+func (f *ListFormatter) Format(value any) string {
+	items, err := listItems(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	locale := f.locale
+	if locale == "" {
+		locale = Locale()
+	}
+	return list.Format(locale, items, f.listType, f.listWidth)
+}
+
+func (f *ListFormatter) ArgCount() int {
+	return 1
+}
@@ -0,0 +1,50 @@
+package format
+
+import (
+	"fmt"
+	"sync"
+)
+
+//MapInterpreter 基于map的表达式解释器，键对应的值作为格式化字符串，使用调用时的实参渲染
+//例如注册"greet": "Hello, {0}!"后，{{greet($0)}}与Fmt("Hello, {0}!", $0)的结果相同。
+//格式化字符串之间的引用与模板一样受MaxIncludeDepth限制，循环引用返回ErrTemplateCycle
+type MapInterpreter struct {
+	mu       sync.RWMutex
+	messages map[string]string
+}
+
+//NewMapInterpreter 创建解释器，messages会被复制
+func NewMapInterpreter(messages map[string]string) *MapInterpreter {
+	i := &MapInterpreter{messages: make(map[string]string, len(messages))}
+	for key, pattern := range messages {
+		i.messages[key] = pattern
+	}
+	return i
+}
+
+//Set 设置键对应的格式化字符串
+func (i *MapInterpreter) Set(key string, pattern string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.messages[key] = pattern
+}
+
+//Get 返回键对应的格式化字符串
+func (i *MapInterpreter) Get(key string) (string, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	pattern, ok := i.messages[key]
+	return pattern, ok
+}
+
+func (i *MapInterpreter) Pattern(key string) (string, error) {
+	pattern, ok := i.Get(key)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+	return pattern, nil
+}
+
+func (i *MapInterpreter) Format(key string, args []any) (string, error) {
+	return FormatPattern(i, key, args)
+}
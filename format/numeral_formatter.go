@@ -0,0 +1,155 @@
+package format
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/Khellendros97/khutils/numeral"
+)
+
+const (
+	//NUMERAL_FORMATTER_LABEL 使用其他数字系统输出数值的格式化器
+	//{0:#roman}、{0:#hans}、{0:#hantfin}、{0:#fullwide,group}、{0:#deva,%.2f}
+	NUMERAL_FORMATTER_LABEL = '#'
+	//ORDINAL_FORMATTER_LABEL 按序数复数规则输出序数的格式化器
+	//{0:^} => 1st，{0:^zh} => 第1，{0:^fr} => 1er，{0:^en,group} => 1,001st
+	ORDINAL_FORMATTER_LABEL = '^'
+	//NUMERAL_FORMATTER_NAME、ORDINAL_FORMATTER_NAME 两个格式化器的名字，例如{0:numeral(roman)}、{0:ordinal(fr)}
+	NUMERAL_FORMATTER_NAME = "numeral"
+	ORDINAL_FORMATTER_NAME = "ordinal"
+	//NUMERAL_GROUP_OPTION 按3位分组的选项，分隔符随数字系统变化
+	NUMERAL_GROUP_OPTION = "group"
+)
+
+//numberSpec 数字格式化器共用的选项：数字系统、分组以及std格式化器的参数，序数格式化器还可以指定语言
+type numberSpec struct {
+	system numeral.System
+	group  bool
+	verb   string
+	locale string
+}
+
+//parse 解析逗号分隔的选项
+//@params allowLocale 是否接受语言选项
+func (s *numberSpec) parse(token string, allowLocale bool) error {
+	for _, option := range strings.Split(token, ",") {
+		option = strings.TrimSpace(option)
+		switch {
+		case option == "":
+		case option == NUMERAL_GROUP_OPTION:
+			s.group = true
+		case option[0] == STD_FORMATTER_LABEL:
+			s.verb = option[1:]
+		default:
+			if system, ok := numeral.ParseSystem(option); ok {
+				s.system = system
+			} else if allowLocale && isLocale(option) && s.locale == "" {
+				s.locale = option
+			} else {
+				return fmt.Errorf("invalid numeral option: %q", option)
+			}
+		}
+	}
+	if s.verb != "" && s.system != "" && !numeral.IsDigitSystem(s.system) {
+		return fmt.Errorf("numeral system %s does not accept %%%s", s.system, s.verb)
+	}
+	return nil
+}
+
+//integerOf 整数值，值为整数的浮点数和数字字符串也可以
+func integerOf(value any) (int64, bool) {
+	v := ToValue(value)
+	if n, ok := v.Int(); ok {
+		return n, true
+	}
+	if f, ok := asNumber(v); ok && f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+		return int64(f), true
+	}
+	return 0, false
+}
+
+//format 按选项输出数字，值不能用该数字系统表示时返回false
+func (s *numberSpec) format(value any) (string, bool) {
+	switch s.system {
+	case numeral.Roman, numeral.RomanLower:
+		n, ok := integerOf(value)
+		if !ok {
+			return "", false
+		}
+		str, err := numeral.RomanNumeral(n)
+		if s.system == numeral.RomanLower {
+			str = strings.ToLower(str)
+		}
+		return str, err == nil
+	case numeral.Chinese, numeral.ChineseTraditional, numeral.ChineseFinancial, numeral.ChineseTraditionalFinancial:
+		n, ok := integerOf(value)
+		if !ok {
+			return "", false
+		}
+		str, err := numeral.ChineseNumeral(n, s.system)
+		return str, err == nil
+	}
+	str := fmt.Sprintf("%v", value)
+	if s.verb != "" {
+		str = fmt.Sprintf("%"+s.verb, value)
+	}
+	if s.group {
+		str = numeral.Group(str, ",")
+	}
+	if s.system != "" {
+		str, _ = numeral.Digits(str, s.system)
+	}
+	return str, true
+}
+
+type NumeralFormatter struct {
+	spec numberSpec
+}
+
+func NewNumeralFormatter() IValueFormatter {
+	return &NumeralFormatter{}
+}
+
+func (f *NumeralFormatter) Parse(token string) (err error) {
+	return f.spec.parse(token, false)
+}
+
+func (f *NumeralFormatter) Format(value any) string {
+	if str, ok := f.spec.format(value); ok {
+		return str
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func (f *NumeralFormatter) ArgCount() int {
+	return 1
+}
+
+type OrdinalFormatter struct {
+	spec numberSpec
+}
+
+func NewOrdinalFormatter() IValueFormatter {
+	return &OrdinalFormatter{}
+}
+
+func (f *OrdinalFormatter) Parse(token string) (err error) {
+	return f.spec.parse(token, true)
+}
+
+func (f *OrdinalFormatter) Format(value any) string {
+	str, ok := f.spec.format(value)
+	if !ok {
+		return fmt.Sprintf("%v", value)
+	}
+	locale := f.spec.locale
+	if locale == "" {
+		locale = Locale()
+	}
+	return numeral.Ordinal(locale, value, str)
+}
+
+func (f *OrdinalFormatter) ArgCount() int {
+	return 1
+}
@@ -0,0 +1,97 @@
+package format
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//LookupEvent 一次表达式解释器查找
+type LookupEvent struct {
+	Namespace string        // 命名空间（省略时为默认解释器的命名空间）
+	Key       string        // 变量名或函数名
+	Locale    string        // 解释器实现了ILocaleInterpreter时为其语言，否则为空
+	Hit       bool          // 是否查找成功
+	Err       error         // 查找失败的原因，键不存在时包装了ErrKeyNotFound或ErrNamespaceNotFound
+	Duration  time.Duration // 查找耗时
+}
+
+//Missing 是否因为键或命名空间不存在而失败
+func (e *LookupEvent) Missing() bool {
+	return !e.Hit && IsNotFound(e.Err)
+}
+
+//FormatterEvent 一次格式化器调用
+type FormatterEvent struct {
+	Label    byte   // 格式化器标签，没有指定格式化器（例如{0}）或使用命名格式化器时为0
+	Name     string // 命名格式化器的名字，例如{0:money(USD, 2)}为money
+	Spec     string // 标签或名字后面的格式，例如{:%.2f}为.2f，{0:money(USD, 2)}为(USD, 2)
+	Duration time.Duration
+}
+
+//IObserver 观察表达式查找和格式化器调用，可以用于统计缺失和未使用的键
+//回调在格式化的过程中同步执行，可能被多个goroutine并发调用，应该尽快返回
+type IObserver interface {
+	OnLookup(e LookupEvent)
+	OnFormat(e FormatterEvent)
+}
+
+//ILocaleInterpreter 可以报告当前语言的表达式解释器
+type ILocaleInterpreter interface {
+	Locale() string
+}
+
+//observers 已添加的观察者，添加和移除时整体替换，格式化时读取不需要加锁
+var (
+	observersMu sync.Mutex
+	observers   atomic.Pointer[[]IObserver]
+)
+
+//AddObserver 添加观察者
+func AddObserver(o IObserver) {
+	observersMu.Lock()
+	defer observersMu.Unlock()
+	var list []IObserver
+	if old := observers.Load(); old != nil {
+		list = append(list, *old...)
+	}
+	list = append(list, o)
+	observers.Store(&list)
+}
+
+//RemoveObserver 移除观察者
+func RemoveObserver(o IObserver) {
+	observersMu.Lock()
+	defer observersMu.Unlock()
+	old := observers.Load()
+	if old == nil {
+		return
+	}
+	var list []IObserver
+	for _, item := range *old {
+		if item != o {
+			list = append(list, item)
+		}
+	}
+	observers.Store(&list)
+}
+
+//loadObservers 返回当前的观察者，没有观察者时返回nil，调用方据此跳过计时
+func loadObservers() []IObserver {
+	if list := observers.Load(); list != nil {
+		return *list
+	}
+	return nil
+}
+
+func notifyLookup(list []IObserver, e LookupEvent) {
+	for _, o := range list {
+		o.OnLookup(e)
+	}
+}
+
+func notifyFormat(list []IObserver, e FormatterEvent) {
+	for _, o := range list {
+		o.OnFormat(e)
+	}
+}
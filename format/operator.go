@@ -0,0 +1,337 @@
+package format
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+//Associativity 二元操作符的结合性
+type Associativity int
+
+const (
+	ASSOC_LEFT  Associativity = iota // 左结合：a - b - c = (a - b) - c
+	ASSOC_RIGHT                      // 右结合：a ?? b ?? c = a ?? (b ?? c)
+)
+
+//操作符的优先级，数值越大结合越紧密。自定义操作符可以使用这些常量或者它们之间的值
+const (
+	PRECEDENCE_PIPE     = 1   // a | filter
+	PRECEDENCE_TERNARY  = 5   // a ? b : c
+	PRECEDENCE_COALESCE = 10  // ??
+	PRECEDENCE_OR       = 20  // ||
+	PRECEDENCE_AND      = 30  // &&
+	PRECEDENCE_EQUALITY = 40  // == !=
+	PRECEDENCE_COMPARE  = 45  // < <= > >=
+	PRECEDENCE_ADD      = 50  // + -
+	PRECEDENCE_MUL      = 60  // * / %
+	PRECEDENCE_UNARY    = 100 // 一元操作符总是比二元操作符结合得更紧密
+)
+
+//由解析器内置处理的操作符，它们按需对右侧求值，不能重新定义
+const (
+	COALESCE_OPERATOR = "??" // 空值合并，左侧引用的键不存在时使用右侧
+	AND_OPERATOR      = "&&" // 左侧为假时返回左侧，否则返回右侧
+	OR_OPERATOR       = "||" // 左侧为真时返回左侧，否则返回右侧
+)
+
+//builtinOperators 内置操作符的优先级
+var builtinOperators = map[string]int{
+	COALESCE_OPERATOR: PRECEDENCE_COALESCE,
+	AND_OPERATOR:      PRECEDENCE_AND,
+	OR_OPERATOR:       PRECEDENCE_OR,
+}
+
+//OperatorFunc 按操作数类型注册的二元操作符函数
+type OperatorFunc func(a, b Value) (Value, error)
+
+//UnaryOperatorFunc 按操作数类型注册的一元操作符函数
+type UnaryOperatorFunc func(v Value) (Value, error)
+
+type operatorOverload struct {
+	left  ValueKind
+	right ValueKind
+	fn    OperatorFunc
+}
+
+type unaryOverload struct {
+	kind ValueKind
+	fn   UnaryOperatorFunc
+}
+
+//BinaryOperator 二元操作符
+type BinaryOperator struct {
+	Symbol     string
+	Precedence int
+	Assoc      Associativity
+	Fn         func(s1, s2 string) string // 没有匹配操作数类型的函数时，将两侧转换为字符串调用，可以为nil
+
+	overloads []operatorOverload
+}
+
+//Apply 按操作数类型选择函数计算：先查找类型完全匹配的函数，再查找使用VALUE_ANY的函数，最后使用Fn
+func (op *BinaryOperator) Apply(a, b Value) (Value, error) {
+	for _, wildcard := range []bool{false, true} {
+		for _, o := range op.overloads {
+			if matchKind(o.left, a.Kind(), wildcard) && matchKind(o.right, b.Kind(), wildcard) {
+				return o.fn(a, b)
+			}
+		}
+	}
+	if op.Fn != nil {
+		return StringValue(op.Fn(a.String(), b.String())), nil
+	}
+	return Nil, fmt.Errorf("operator %s is not defined for %s and %s", op.Symbol, a.Kind(), b.Kind())
+}
+
+//UnaryOperator 前缀一元操作符
+type UnaryOperator struct {
+	Symbol string
+	Fn     func(s string) string // 没有匹配操作数类型的函数时，将操作数转换为字符串调用，可以为nil
+
+	overloads []unaryOverload
+}
+
+//Apply 按操作数类型选择函数计算，规则与BinaryOperator.Apply相同
+func (op *UnaryOperator) Apply(v Value) (Value, error) {
+	for _, wildcard := range []bool{false, true} {
+		for _, o := range op.overloads {
+			if matchKind(o.kind, v.Kind(), wildcard) {
+				return o.fn(v)
+			}
+		}
+	}
+	if op.Fn != nil {
+		return StringValue(op.Fn(v.String())), nil
+	}
+	return Nil, fmt.Errorf("unary operator %s is not defined for %s", op.Symbol, v.Kind())
+}
+
+func matchKind(want ValueKind, kind ValueKind, wildcard bool) bool {
+	if wildcard {
+		return want == VALUE_ANY
+	}
+	return want == kind
+}
+
+//checkOperatorSymbol 操作符不能为空，不能包含空白和表达式语法使用的字符，|用于过滤器
+func checkOperatorSymbol(symbol string) error {
+	if symbol == "" || strings.ContainsAny(symbol, " \t\r\n'$(),{}?:") || symbol == "|" {
+		return fmt.Errorf("invalid operator: %q", symbol)
+	}
+	return nil
+}
+
+//RegisterOperator 注册二元操作符，替换同名操作符及其按类型注册的函数
+//@params symbol 操作符，可以是多个字符，例如"<>"，也可以是单词，例如"and"; precedence 优先级; assoc 结合性
+//@params fn 操作数转换为字符串后调用的函数，只允许按类型注册的函数时传入nil
+func (f *ExprFormatterConfig) RegisterOperator(symbol string, precedence int, assoc Associativity, fn func(s1, s2 string) string) error {
+	if _, ok := builtinOperators[symbol]; ok {
+		return fmt.Errorf("operator %s can not be redefined", symbol)
+	}
+	if err := checkOperatorSymbol(symbol); err != nil {
+		return err
+	}
+	f.Operators[symbol] = &BinaryOperator{Symbol: symbol, Precedence: precedence, Assoc: assoc, Fn: fn}
+	return nil
+}
+
+//RegisterOperatorFunc 为已注册的二元操作符添加按操作数类型调用的函数，VALUE_ANY匹配任意类型，
+//同一类型组合重复注册时替换原来的函数。例如为*添加字符串重复：
+//RegisterOperatorFunc("*", VALUE_STRING, VALUE_NUMBER, repeat)
+func (f *ExprFormatterConfig) RegisterOperatorFunc(symbol string, left ValueKind, right ValueKind, fn OperatorFunc) error {
+	op, ok := f.Operators[symbol]
+	if !ok {
+		return fmt.Errorf("operator %s is not registered", symbol)
+	}
+	overload := operatorOverload{left: left, right: right, fn: fn}
+	for i, o := range op.overloads {
+		if o.left == left && o.right == right {
+			op.overloads[i] = overload
+			return nil
+		}
+	}
+	op.overloads = append(op.overloads, overload)
+	return nil
+}
+
+//RegisterUnaryOperator 注册前缀一元操作符，同一个符号可以同时是一元和二元操作符，例如-
+func (f *ExprFormatterConfig) RegisterUnaryOperator(symbol string, fn func(s string) string) error {
+	if err := checkOperatorSymbol(symbol); err != nil {
+		return err
+	}
+	f.UnaryOps[symbol] = &UnaryOperator{Symbol: symbol, Fn: fn}
+	return nil
+}
+
+//RegisterUnaryOperatorFunc 为一元操作符添加按操作数类型调用的函数，操作符不存在时先注册它
+func (f *ExprFormatterConfig) RegisterUnaryOperatorFunc(symbol string, kind ValueKind, fn UnaryOperatorFunc) error {
+	op, ok := f.UnaryOps[symbol]
+	if !ok {
+		if err := f.RegisterUnaryOperator(symbol, nil); err != nil {
+			return err
+		}
+		op = f.UnaryOps[symbol]
+	}
+	overload := unaryOverload{kind: kind, fn: fn}
+	for i, o := range op.overloads {
+		if o.kind == kind {
+			op.overloads[i] = overload
+			return nil
+		}
+	}
+	op.overloads = append(op.overloads, overload)
+	return nil
+}
+
+//SetFnConcat 设置单字符二元操作符的函数，已注册的操作符保留原有的优先级、结合性和按类型注册的函数，
+//新的操作符使用PRECEDENCE_ADD和左结合
+func (f *ExprFormatterConfig) SetFnConcat(key byte, fn func(s1, s2 string) string) {
+	symbol := string([]byte{key})
+	if op, ok := f.Operators[symbol]; ok {
+		f.Operators[symbol] = &BinaryOperator{Symbol: symbol, Precedence: op.Precedence, Assoc: op.Assoc, Fn: fn, overloads: op.overloads}
+		return
+	}
+	f.RegisterOperator(symbol, PRECEDENCE_ADD, ASSOC_LEFT, fn)
+}
+
+//isWordOperator 操作符是否由标签字符组成，例如and，这样的操作符后面不能紧跟标签字符
+func isWordOperator(symbol string) bool {
+	for _, ch := range symbol {
+		if !isLabelChar(ch) {
+			return false
+		}
+	}
+	return true
+}
+
+//matchOperator 返回s开头最长的操作符
+func matchOperator(s string, symbols func(yield func(string) bool)) string {
+	longest := ""
+	for symbol := range symbols {
+		if len(symbol) <= len(longest) || !strings.HasPrefix(s, symbol) {
+			continue
+		}
+		if next, _ := utf8.DecodeRuneInString(s[len(symbol):]); isWordOperator(symbol) && isLabelChar(next) {
+			continue
+		}
+		longest = symbol
+	}
+	return longest
+}
+
+//ErrDivisionByZero 除数为0
+var ErrDivisionByZero = errors.New("division by zero")
+
+//arithmetic 数值运算：两侧都是整数且intFn没有溢出时结果为整数，否则按浮点数计算
+func arithmetic(intFn func(x, y int64) (int64, bool), floatFn func(x, y float64) float64) OperatorFunc {
+	return func(a, b Value) (Value, error) {
+		if x, ok := a.Int(); ok {
+			if y, ok := b.Int(); ok {
+				if n, ok := intFn(x, y); ok {
+					return NumberValue(n), nil
+				}
+			}
+		}
+		x, _ := a.Float()
+		y, _ := b.Float()
+		return NumberValue(floatFn(x, y)), nil
+	}
+}
+
+//comparison 使用Compare比较两侧，pred判断比较结果
+func comparison(pred func(n int) bool) OperatorFunc {
+	return func(a, b Value) (Value, error) {
+		n, err := Compare(a, b)
+		if err != nil {
+			return Nil, err
+		}
+		return BoolValue(pred(n)), nil
+	}
+}
+
+//registerBuiltinOperators 注册内置的操作符：算术操作符+ - * / %和一元-，+对字符串连接、对列表合并；
+//比较操作符== != < <= > >=和一元!，比较的结果为布尔值
+func (f *ExprFormatterConfig) registerBuiltinOperators() {
+	f.RegisterOperator("+", PRECEDENCE_ADD, ASSOC_LEFT, func(s1, s2 string) string {
+		return s1 + s2
+	})
+	f.RegisterOperator("-", PRECEDENCE_ADD, ASSOC_LEFT, nil)
+	f.RegisterOperator("*", PRECEDENCE_MUL, ASSOC_LEFT, nil)
+	f.RegisterOperator("/", PRECEDENCE_MUL, ASSOC_LEFT, nil)
+	f.RegisterOperator("%", PRECEDENCE_MUL, ASSOC_LEFT, nil)
+	for symbol, precedence := range map[string]int{
+		"==": PRECEDENCE_EQUALITY, "!=": PRECEDENCE_EQUALITY,
+		"<": PRECEDENCE_COMPARE, "<=": PRECEDENCE_COMPARE, ">": PRECEDENCE_COMPARE, ">=": PRECEDENCE_COMPARE,
+	} {
+		f.RegisterOperator(symbol, precedence, ASSOC_LEFT, nil)
+	}
+
+	f.RegisterOperatorFunc("+", VALUE_NUMBER, VALUE_NUMBER, arithmetic(func(x, y int64) (int64, bool) {
+		n := x + y
+		return n, (n > x) == (y > 0)
+	}, func(x, y float64) float64 {
+		return x + y
+	}))
+	f.RegisterOperatorFunc("+", VALUE_LIST, VALUE_LIST, func(a, b Value) (Value, error) {
+		return ListValue(append(append([]Value{}, a.List()...), b.List()...)), nil
+	})
+	f.RegisterOperatorFunc("-", VALUE_NUMBER, VALUE_NUMBER, arithmetic(func(x, y int64) (int64, bool) {
+		n := x - y
+		return n, (n < x) == (y > 0)
+	}, func(x, y float64) float64 {
+		return x - y
+	}))
+	f.RegisterOperatorFunc("*", VALUE_NUMBER, VALUE_NUMBER, arithmetic(func(x, y int64) (int64, bool) {
+		if x == 0 || y == 0 {
+			return 0, true
+		}
+		n := x * y
+		return n, n/y == x && !(x == -1 && y == math.MinInt64) && !(y == -1 && x == math.MinInt64)
+	}, func(x, y float64) float64 {
+		return x * y
+	}))
+	f.RegisterOperatorFunc("/", VALUE_NUMBER, VALUE_NUMBER, func(a, b Value) (Value, error) {
+		if y, _ := b.Float(); y == 0 {
+			return Nil, ErrDivisionByZero
+		}
+		return arithmetic(func(x, y int64) (int64, bool) {
+			return x / y, x%y == 0 && !(x == math.MinInt64 && y == -1)
+		}, func(x, y float64) float64 {
+			return x / y
+		})(a, b)
+	})
+	f.RegisterOperatorFunc("%", VALUE_NUMBER, VALUE_NUMBER, func(a, b Value) (Value, error) {
+		if y, _ := b.Float(); y == 0 {
+			return Nil, ErrDivisionByZero
+		}
+		return arithmetic(func(x, y int64) (int64, bool) {
+			if y == -1 {
+				return 0, true
+			}
+			return x % y, true
+		}, math.Mod)(a, b)
+	})
+	f.RegisterOperatorFunc("==", VALUE_ANY, VALUE_ANY, func(a, b Value) (Value, error) {
+		return BoolValue(Equal(a, b)), nil
+	})
+	f.RegisterOperatorFunc("!=", VALUE_ANY, VALUE_ANY, func(a, b Value) (Value, error) {
+		return BoolValue(!Equal(a, b)), nil
+	})
+	f.RegisterOperatorFunc("<", VALUE_ANY, VALUE_ANY, comparison(func(n int) bool { return n < 0 }))
+	f.RegisterOperatorFunc("<=", VALUE_ANY, VALUE_ANY, comparison(func(n int) bool { return n <= 0 }))
+	f.RegisterOperatorFunc(">", VALUE_ANY, VALUE_ANY, comparison(func(n int) bool { return n > 0 }))
+	f.RegisterOperatorFunc(">=", VALUE_ANY, VALUE_ANY, comparison(func(n int) bool { return n >= 0 }))
+	f.RegisterUnaryOperatorFunc("!", VALUE_ANY, func(v Value) (Value, error) {
+		return BoolValue(!v.Truthy()), nil
+	})
+	f.RegisterUnaryOperatorFunc("-", VALUE_NUMBER, func(v Value) (Value, error) {
+		if n, ok := v.Int(); ok && n != math.MinInt64 {
+			return NumberValue(-n), nil
+		}
+		n, _ := v.Float()
+		return NumberValue(-n), nil
+	})
+}
@@ -2,20 +2,40 @@ package format
 
 import (
 	"fmt"
+	"maps"
 	"strconv"
+	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
+//Expr 表达式的语法树节点，求值得到带类型的值
 type Expr interface {
-	Eval(*ExprFormatter) (string, error)
+	Eval(*ExprFormatter) (Value, error)
 }
 
-type tokenOp byte
+type tokenOp string
 
 type tokenLiteral string
 
-func (l *tokenLiteral) Eval(env *ExprFormatter) (string, error) {
-	return string(*l), nil
+func (l *tokenLiteral) Eval(env *ExprFormatter) (Value, error) {
+	return StringValue(string(*l)), nil
+}
+
+//tokenBool 布尔常量true或false
+type tokenBool bool
+
+func (l *tokenBool) Eval(env *ExprFormatter) (Value, error) {
+	return BoolValue(bool(*l)), nil
+}
+
+//tokenNumber 数值常量，整数为int64，带小数点的为float64
+type tokenNumber struct {
+	value any
+}
+
+func (l *tokenNumber) Eval(env *ExprFormatter) (Value, error) {
+	return NumberValue(l.value), nil
 }
 
 type tokenLabel string
@@ -24,25 +44,37 @@ type tokenVar struct {
 	key       tokenLabel
 }
 
-func (l *tokenVar) Eval(env *ExprFormatter) (string, error) {
+func (l *tokenVar) Eval(env *ExprFormatter) (Value, error) {
 	return env.EvalVar(string(l.namespace), string(l.key), []any{})
 }
 
+//tokenParam $N，引用第N个格式化参数，保持参数原来的类型
 type tokenParam int
 
+func (l *tokenParam) Eval(env *ExprFormatter) (Value, error) {
+	arg, err := env.GetArg(int(*l))
+	if err != nil {
+		return Nil, err
+	}
+	return ToValue(arg), nil
+}
+
+//tokenFunc 函数调用，实参可以是任意表达式，例如fmtName(upper($0), 'Mr.')
 type tokenFunc struct {
 	namespace tokenLabel
 	key       tokenLabel
-	params    []tokenParam
+	params    []Expr
 }
 
-func (l *tokenFunc) Eval(env *ExprFormatter) (str string, err error) {
+//Eval 先对实参求值再调用函数，实参以原始的Go值传入：$N是格式化参数本身，其他表达式是求值结果
+func (l *tokenFunc) Eval(env *ExprFormatter) (Value, error) {
 	args := make([]any, len(l.params))
 	for i, param := range l.params {
-		args[i], err = env.GetArg(int(param))
+		value, err := param.Eval(env)
 		if err != nil {
-			return
+			return Nil, err
 		}
+		args[i] = value.Interface()
 	}
 	return env.EvalVar(string(l.namespace), string(l.key), args)
 }
@@ -53,40 +85,179 @@ type binaryExpr struct {
 	op    tokenOp
 }
 
-func (e *binaryExpr) Eval(env *ExprFormatter) (str string, err error) {
-	fn, ok := env.BinOps[byte(e.op)]
+//Eval 操作符在求值时查找，SetOperate修改后立即生效
+func (e *binaryExpr) Eval(env *ExprFormatter) (Value, error) {
+	op, ok := env.Operators[string(e.op)]
 	if !ok {
-		return "", fmt.Errorf("unknown operator: %c", e.op)
+		return Nil, fmt.Errorf("unknown operator: %s", e.op)
 	}
 	vl, err := e.left.Eval(env)
 	if err != nil {
-		return "", err
+		return Nil, err
 	}
 	vr, err := e.right.Eval(env)
 	if err != nil {
-		return "", err
+		return Nil, err
 	}
-	str = fn(vl, vr)
-	return
+	return op.Apply(vl, vr)
+}
+
+//unaryExpr 前缀一元操作符
+type unaryExpr struct {
+	operand Expr
+	op      tokenOp
+}
+
+func (e *unaryExpr) Eval(env *ExprFormatter) (Value, error) {
+	op, ok := env.UnaryOps[string(e.op)]
+	if !ok {
+		return Nil, fmt.Errorf("unknown unary operator: %s", e.op)
+	}
+	v, err := e.operand.Eval(env)
+	if err != nil {
+		return Nil, err
+	}
+	return op.Apply(v)
+}
+
+//coalesceExpr a ?? b，a引用的键或命名空间不存在时使用b
+type coalesceExpr struct {
+	left  Expr
+	right Expr
+}
+
+func (e *coalesceExpr) Eval(env *ExprFormatter) (Value, error) {
+	env.coalescing++
+	value, err := e.left.Eval(env)
+	env.coalescing--
+	if err != nil && IsNotFound(err) {
+		return e.right.Eval(env)
+	}
+	return value, err
+}
+
+//logicalExpr a && b或a || b，左侧已经决定结果时不对右侧求值
+type logicalExpr struct {
+	left  Expr
+	right Expr
+	op    tokenOp
+}
+
+func (e *logicalExpr) Eval(env *ExprFormatter) (Value, error) {
+	value, err := e.left.Eval(env)
+	if err != nil {
+		return Nil, err
+	}
+	if value.Truthy() == (e.op == OR_OPERATOR) {
+		return value, nil
+	}
+	return e.right.Eval(env)
+}
+
+//ternaryExpr cond ? a : b，只对选中的分支求值
+type ternaryExpr struct {
+	cond Expr
+	then Expr
+	els  Expr
+}
+
+func (e *ternaryExpr) Eval(env *ExprFormatter) (Value, error) {
+	cond, err := e.cond.Eval(env)
+	if err != nil {
+		return Nil, err
+	}
+	if cond.Truthy() {
+		return e.then.Eval(env)
+	}
+	return e.els.Eval(env)
+}
+
+//includeExpr {{> name}}或{{tpl::name(args...)}}，插入模板
+type includeExpr struct {
+	name    tokenLabel
+	params  []Expr
+	forward bool // 没有括号时传入当前的参数
+}
+
+func (e *includeExpr) Eval(env *ExprFormatter) (Value, error) {
+	args := env.Args
+	if !e.forward {
+		args = make([]any, len(e.params))
+		for i, param := range e.params {
+			value, err := param.Eval(env)
+			if err != nil {
+				return Nil, err
+			}
+			args[i] = value.Interface()
+		}
+	}
+	return env.include(string(e.name), args)
+}
+
+//filterCall 过滤器及其实参，例如trunc(20)
+type filterCall struct {
+	name   tokenLabel
+	params []Expr
+}
+
+//apply 对实参求值并调用过滤器
+func (c *filterCall) apply(env *ExprFormatter, value Value) (Value, error) {
+	args := make([]Value, len(c.params))
+	for i, param := range c.params {
+		arg, err := param.Eval(env)
+		if err != nil {
+			return Nil, err
+		}
+		args[i] = arg
+	}
+	return env.applyFilter(string(c.name), value, args)
+}
+
+//pipeExpr value | filter
+type pipeExpr struct {
+	value  Expr
+	filter *filterCall
+}
+
+func (e *pipeExpr) Eval(env *ExprFormatter) (Value, error) {
+	value, err := e.value.Eval(env)
+	if err != nil {
+		return Nil, err
+	}
+	return e.filter.apply(env, value)
 }
 
+//ExprParser 表达式解析器，使用优先级爬升（Pratt）算法解析二元操作符：
+//操作符的优先级和结合性在注册时声明，支持括号、多字符操作符和前缀一元操作符
 type ExprParser struct {
-	expr string
-	pos  int
+	expr   string
+	pos    int
+	config *ExprFormatterConfig
 }
 
+//NewExprParser 创建使用全局环境中已注册操作符的解析器
 func NewExprParser(expr string) *ExprParser {
-	return &ExprParser{expr: expr}
+	return env.exprFormatterConfig.NewParser(expr)
 }
 
-func (p *ExprParser) next() (ch byte, err error) {
-	//fmt.Printf("next: ")
-	if p.pos >= len(p.expr) {
-		return 0, IterEndError{}
+//NewParser 创建使用该配置中已注册操作符的解析器
+func (f *ExprFormatterConfig) NewParser(expr string) *ExprParser {
+	return &ExprParser{expr: expr, config: f}
+}
+
+//errorf 返回带有出错位置的错误，位置是从1开始的字符（rune）列号
+func (p *ExprParser) errorf(format string, args ...any) error {
+	pos := min(p.pos, len(p.expr))
+	return fmt.Errorf("%s at column %d in %q", fmt.Sprintf(format, args...), utf8.RuneCountInString(p.expr[:pos])+1, p.expr)
+}
+
+//next 读取下一个非空格的字符
+func (p *ExprParser) next() (ch rune, err error) {
+	ch, err = p.current()
+	if err != nil {
+		return
 	}
-	ch = p.expr[p.pos]
-	//fmt.Printf("%c\n", ch)
-	p.pos++
+	p.pos += utf8.RuneLen(ch)
 	if ch == ' ' {
 		return p.next()
 	}
@@ -95,7 +266,7 @@ func (p *ExprParser) next() (ch byte, err error) {
 
 func (p *ExprParser) skipSpace() {
 	for p.pos < len(p.expr) {
-		if p.expr[p.pos] == ' ' {
+		if p.expr[p.pos] == ' ' || p.expr[p.pos] == '\t' || p.expr[p.pos] == '\n' || p.expr[p.pos] == '\r' {
 			p.pos++
 		} else {
 			return
@@ -103,20 +274,19 @@ func (p *ExprParser) skipSpace() {
 	}
 }
 
-func (p *ExprParser) current() (ch byte, err error) {
+//current 返回当前位置的字符，不合法的UTF-8编码作为错误返回
+func (p *ExprParser) current() (ch rune, err error) {
 	if p.pos >= len(p.expr) {
 		return 0, IterEndError{}
 	}
-	ch = p.expr[p.pos]
-	return
-}
-
-func (p *ExprParser) getPos() int {
-	p.current()
-	return p.pos
+	ch, size := utf8.DecodeRuneInString(p.expr[p.pos:])
+	if ch == utf8.RuneError && size <= 1 {
+		return 0, p.errorf("invalid UTF-8 encoding")
+	}
+	return ch, nil
 }
 
-func (p *ExprParser) Expect(pred func(byte)bool) (bool, error) {
+func (p *ExprParser) Expect(pred func(rune) bool) (bool, error) {
 	if p.pos >= len(p.expr) {
 		return false, IterEndError{}
 	}
@@ -125,14 +295,14 @@ func (p *ExprParser) Expect(pred func(byte)bool) (bool, error) {
 		return false, err
 	}
 
-	if pred(ch)  {
+	if pred(ch) {
 		return true, nil
 	}
 	return false, nil
 }
 
 func (p *ExprParser) ExpectString(str string) (bool, error) {
-	for _, ch := range []byte(str) {
+	for _, ch := range str {
 		ok, err := p.Expect(isChar(ch))
 		if err != nil {
 			return false, err
@@ -144,6 +314,7 @@ func (p *ExprParser) ExpectString(str string) (bool, error) {
 	return true, nil
 }
 
+//Advance 跳过step个字符
 func (p *ExprParser) Advance(step int) error {
 	for i := 0; i < step; i++ {
 		_, err := p.next()
@@ -154,7 +325,7 @@ func (p *ExprParser) Advance(step int) error {
 	return nil
 }
 
-func (p *ExprParser) Require(pred func(byte)bool) (bool, error) {
+func (p *ExprParser) Require(pred func(rune) bool) (bool, error) {
 	if p.pos >= len(p.expr) {
 		return false, IterEndError{}
 	}
@@ -175,17 +346,21 @@ func (p *ExprParser) RequireString(str string) (bool, error) {
 	return ok, err
 }
 
-func isOp(ch byte) bool {
-	switch ch {
-	case '+': return true
-	default: return false
+func isChar(ch rune) func(rune) bool {
+	return func(ch2 rune) bool {
+		return ch == ch2
 	}
 }
 
-func isChar(ch byte) func (byte) bool {
-	return func (ch2 byte) bool {
-		return ch == ch2
-	}
+//isLabelChar 标签可以包含的字符：按UAX #31，字母、字母数字（Nl）、十进制数字、组合标记和连接标点，以及.
+func isLabelChar(ch rune) bool {
+	return ch == '.' || unicode.In(ch, unicode.L, unicode.Nl, unicode.Nd, unicode.Mn, unicode.Mc, unicode.Pc,
+		unicode.Other_ID_Start, unicode.Other_ID_Continue)
+}
+
+//isLabelStart 标签的第一个字符不能是组合标记
+func isLabelStart(ch rune) bool {
+	return isLabelChar(ch) && !unicode.In(ch, unicode.Mn, unicode.Mc)
 }
 
 func (p *ExprParser) residue() string {
@@ -195,270 +370,408 @@ func (p *ExprParser) residue() string {
 	return ""
 }
 
+//ParseExpr 解析整个表达式，表达式后面有多余的内容时返回错误
 func (p *ExprParser) ParseExpr() (Expr, error) {
-	//fmt.Println(">>> expr", p.residue())
-	if p.pos >= len(p.expr) {
-		return nil, IterEndError{} // End of iteration
-	}
-	p.skipSpace()
-	pos := p.pos
-	be, err := p.parseBinaryExpr()
-	if err == nil || IsIterEnd(err) {
-		return be, nil
-	} else {
-		//fmt.Println(err)
-		p.pos = pos
-	}
-	fn, err := p.parseFunc()
-	if err == nil || IsIterEnd(err) {
-		return fn, nil
-	} else {
-		//fmt.Println(err)
-		p.pos = pos
-	}
-	variable, err := p.parseVariable()
-	if err == nil || IsIterEnd(err) {
-		return variable, nil
-	} else {
-		//fmt.Println(err)
-		p.pos = pos
-	}
-	literal, err := p.parseLiteral()
-	if err == nil || IsIterEnd(err) {
-		return literal, nil
-	}
-	//fmt.Println(err)
-	return nil, err
-}
-
-func (p *ExprParser) ParseUnitExpr() (Expr, error) {
-	//fmt.Println(">>> unit expr", p.residue())
 	p.skipSpace()
-	pos := p.pos
 	if p.pos >= len(p.expr) {
 		return nil, IterEndError{} // End of iteration
 	}
-	fn, err := p.parseFunc()
-	if err == nil || IsIterEnd(err) {
-		return fn, nil
-	} else {
-		p.pos = pos
-	}
-	variable, err := p.parseVariable()
-	if err == nil || IsIterEnd(err) {
-		return variable, nil
-	} else {
-		p.pos = pos
+	ex, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
 	}
-	literal, err := p.parseLiteral()
-	if err == nil || IsIterEnd(err) {
-		return literal, nil
+	p.skipSpace()
+	if p.pos < len(p.expr) {
+		return nil, p.errorf("unexpected %q", p.residue())
 	}
-	return nil, err
+	return ex, nil
 }
 
-func (p *ExprParser) parseBinaryExpr() (*binaryExpr, error) {
-	//fmt.Println(">>> binary", p.residue())
+//parseExpr 解析优先级不低于minPrecedence的二元表达式
+func (p *ExprParser) parseExpr(minPrecedence int) (Expr, error) {
 	left, err := p.ParseUnitExpr()
 	if err != nil {
 		return nil, err
 	}
-	p.skipSpace()
-	isop, err := p.Require(isOp)
-	if err != nil {
-		return nil, fmt.Errorf("missing operator")
+	for {
+		p.skipSpace()
+		symbol, precedence, assoc := p.peekBinaryOperator()
+		if symbol == "" && minPrecedence <= PRECEDENCE_PIPE {
+			if ok, _ := p.Require(isChar('|')); ok {
+				p.pos++
+				filter, err := p.parseFilter()
+				if err != nil {
+					return nil, err
+				}
+				left = &pipeExpr{value: left, filter: filter}
+				continue
+			}
+		}
+		if symbol == "" && minPrecedence <= PRECEDENCE_TERNARY {
+			if ok, _ := p.Require(isChar('?')); ok {
+				if left, err = p.parseTernary(left); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+		if symbol == "" || precedence < minPrecedence {
+			return left, nil
+		}
+		p.pos += len(symbol)
+		next := precedence + 1
+		if assoc == ASSOC_RIGHT {
+			next = precedence
+		}
+		right, err := p.parseExpr(next)
+		if err != nil {
+			return nil, err
+		}
+		switch symbol {
+		case COALESCE_OPERATOR:
+			left = &coalesceExpr{left: left, right: right}
+		case AND_OPERATOR, OR_OPERATOR:
+			left = &logicalExpr{left: left, right: right, op: tokenOp(symbol)}
+		default:
+			left = &binaryExpr{left: left, right: right, op: tokenOp(symbol)}
+		}
 	}
+}
 
-	if !isop {
-		return nil, fmt.Errorf("invalid operator: %c")
+//parseTernary 解析cond后面的? a : b，右结合：a ? b : c ? d : e = a ? b : (c ? d : e)
+func (p *ExprParser) parseTernary(cond Expr) (Expr, error) {
+	p.pos++
+	then, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
 	}
-	op, _ := p.current()
-	p.Advance(1)
-
-	right, err := p.ParseExpr()
+	p.skipSpace()
+	if ok, _ := p.Require(isChar(':')); !ok {
+		return nil, p.errorf("missing ':'")
+	}
+	p.pos++
+	els, err := p.parseExpr(PRECEDENCE_TERNARY)
 	if err != nil {
 		return nil, err
 	}
+	return &ternaryExpr{cond: cond, then: then, els: els}, nil
+}
 
-	return &binaryExpr{left: left, right: right, op: tokenOp(op)}, nil
+//peekBinaryOperator 返回当前位置最长的二元操作符，没有时返回空字符串
+func (p *ExprParser) peekBinaryOperator() (string, int, Associativity) {
+	rest := p.residue()
+	symbol := matchOperator(rest, maps.Keys(p.config.Operators))
+	if builtin := matchOperator(rest, maps.Keys(builtinOperators)); builtin != "" && len(symbol) <= len(builtin) {
+		if builtin == COALESCE_OPERATOR {
+			return builtin, builtinOperators[builtin], ASSOC_RIGHT
+		}
+		return builtin, builtinOperators[builtin], ASSOC_LEFT
+	}
+	if symbol == "" {
+		return "", 0, ASSOC_LEFT
+	}
+	op := p.config.Operators[symbol]
+	return symbol, op.Precedence, op.Assoc
 }
 
-func (p *ExprParser) parseFunc() (*tokenFunc, error) {
-	//fmt.Println(">>> func", p.residue())
-	v, err := p.parseName()
+//ParseUnitExpr 解析一元表达式：前缀操作符、括号、$N参数、字面量、变量或函数调用
+func (p *ExprParser) ParseUnitExpr() (Expr, error) {
+	p.skipSpace()
+	ch, err := p.current()
 	if err != nil {
-		//fmt.Println(err)
-		return nil, err
+		return nil, p.errorf("unexpected end of expression")
 	}
-	ok, err := p.Expect(isChar('('))
-	if err != nil {
-		//fmt.Println(err)
-		return nil, fmt.Errorf("missing (")
+	if ch == '>' {
+		return p.parseInclude()
 	}
-	if !ok {
-		return nil, fmt.Errorf("missing '('")
+	if symbol := matchOperator(p.residue(), maps.Keys(p.config.UnaryOps)); symbol != "" {
+		p.pos += len(symbol)
+		operand, err := p.parseExpr(PRECEDENCE_UNARY)
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{operand: operand, op: tokenOp(symbol)}, nil
 	}
-	params := make([]tokenParam, 0)
-	for {
-		param, err := p.parseParam()
+	switch {
+	case ch == '(':
+		p.pos++
+		ex, err := p.parseExpr(0)
 		if err != nil {
-			break
+			return nil, err
 		}
-		params = append(params, *param)
-		ok, err := p.Require(isChar(','))
+		p.skipSpace()
+		if ok, _ := p.Require(isChar(')')); !ok {
+			return nil, p.errorf("missing ')'")
+		}
+		p.pos++
+		return ex, nil
+	case ch == '$':
+		return p.parseParam()
+	case ch == '\'' || ch == '"':
+		return p.parseLiteral()
+	case isLabelStart(ch):
+		return p.parseName()
+	}
+	return nil, p.errorf("unexpected %q", ch)
+}
+
+//parseName 解析变量或函数调用：[namespace::]key[(args...)]，整个标签是数字时作为数值常量，true和false是布尔常量
+func (p *ExprParser) parseName() (Expr, error) {
+	label, err := p.parseLabel()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(p.residue(), "::") {
+		if number, ok := parseNumber(string(*label)); ok {
+			return number, nil
+		}
+		if *label == "true" || *label == "false" {
+			b := tokenBool(*label == "true")
+			return &b, nil
+		}
+	}
+	v := &tokenVar{key: *label}
+	if strings.HasPrefix(p.residue(), "::") {
+		p.pos += 2
+		key, err := p.parseLabel()
 		if err != nil {
-			return nil, fmt.Errorf("missing ','")
+			return nil, err
 		}
-		if !ok {
-			break
+		v.namespace, v.key = *label, *key
+	}
+	if ok, _ := p.Require(isChar('(')); !ok {
+		if v.namespace == TEMPLATE_NAMESPACE {
+			return &includeExpr{name: v.key, forward: true}, nil
 		}
-		p.Advance(1)
+		return v, nil
 	}
-	////fmt.Println("expect )")
-	ok, err = p.Expect(isChar(')'))
+	params, err := p.parseArgs()
 	if err != nil {
-		//fmt.Println(err)
 		return nil, err
 	}
-	if !ok {
-		return nil, fmt.Errorf("missing ')'")
+	if v.namespace == TEMPLATE_NAMESPACE {
+		return &includeExpr{name: v.key, params: params}, nil
 	}
-
-	fn := &tokenFunc{namespace: v.namespace, key: v.key, params: params}
-	//fmt.Println(fn)
-	return fn, nil
+	return &tokenFunc{namespace: v.namespace, key: v.key, params: params}, nil
 }
 
-func (p *ExprParser) parseVariable() (*tokenVar, error) {
-	//fmt.Println(">>> var", p.residue())
-	v, err := p.parseName()
+//parseInclude 解析> name[(args...)]
+func (p *ExprParser) parseInclude() (Expr, error) {
+	p.pos++
+	p.skipSpace()
+	name, err := p.parseLabel()
 	if err != nil {
-		return nil, err
+		return nil, p.errorf("missing template name")
 	}
-	ok ,_ := p.Require(isChar('('))
-	if ok {
-		return nil, fmt.Errorf("unexpect '('")
+	include := &includeExpr{name: *name, forward: true}
+	if ok, _ := p.Require(isChar('(')); ok {
+		if include.params, err = p.parseArgs(); err != nil {
+			return nil, err
+		}
+		include.forward = false
 	}
-	return v, nil
+	return include, nil
 }
 
-func (p *ExprParser) parseName() (*tokenVar, error) {
-	//fmt.Println(">>> name", p.residue())
-	var namespace *tokenLabel
-	var key *tokenLabel
-	label, err := p.parseLabel()
+//parseFilter 解析过滤器：name[(args...)]
+func (p *ExprParser) parseFilter() (*filterCall, error) {
+	p.skipSpace()
+	name, err := p.parseLabel()
 	if err != nil {
-		return nil, err
+		return nil, p.errorf("missing filter name")
 	}
-	ok, err := p.RequireString("::")
-	if err != nil && !IsIterEnd(err) {
-		return nil, err
+	filter := &filterCall{name: *name}
+	if ok, _ := p.Require(isChar('(')); ok {
+		if filter.params, err = p.parseArgs(); err != nil {
+			return nil, err
+		}
 	}
-	if ok {
-		namespace = label
-		p.Advance(2)
-		label, err = p.parseLabel()
+	return filter, nil
+}
+
+//parseFilters 解析占位符中的过滤器链，例如{0|upper|trunc(20)}中的upper|trunc(20)
+func (p *ExprParser) parseFilters() ([]*filterCall, error) {
+	var filters []*filterCall
+	for {
+		filter, err := p.parseFilter()
 		if err != nil {
 			return nil, err
 		}
-		if !ok {
-			return nil, fmt.Errorf("invalid expr: %s", p.expr)
+		filters = append(filters, filter)
+		p.skipSpace()
+		if p.pos >= len(p.expr) {
+			return filters, nil
 		}
-		key = label
-	} else {
-		namespace = new(tokenLabel)
-		key = label
+		if ok, _ := p.Require(isChar('|')); !ok {
+			return nil, p.errorf("unexpected %q", p.residue())
+		}
+		p.pos++
 	}
-	////fmt.Printf("namespace: %s, key: %s", *namespace, *key)
-	return &tokenVar{namespace: *namespace, key: *key}, nil
 }
 
-func (p *ExprParser) parseLiteral() (*tokenLiteral, error) {
-	//fmt.Println(">>> literal", p.residue())
-	var value tokenLiteral
-	ok, err := p.Expect(isChar('\''))
-	if err != nil {
-		return nil, err
-	}
-	if !ok {
-		return nil, fmt.Errorf("missing '\\''")
-	}
-	pstart := p.getPos()
+//parseArgs 解析括号中以逗号分隔的实参
+func (p *ExprParser) parseArgs() ([]Expr, error) {
+	p.pos++
+	params := make([]Expr, 0)
 	for {
-		ok, err := p.Require(isChar('\''))
+		p.skipSpace()
+		if ok, _ := p.Require(isChar(')')); ok {
+			break
+		}
+		param, err := p.parseExpr(0)
 		if err != nil {
 			return nil, err
 		}
-		if ok {
+		params = append(params, param)
+		p.skipSpace()
+		if ok, _ := p.Require(isChar(',')); !ok {
 			break
 		}
-		p.Advance(1)
+		p.pos++
 	}
-	value = tokenLiteral(p.expr[pstart:p.pos])
-	ok, err = p.Expect(isChar('\''))
-	if err != nil {
-		return nil, err
-	}
-	if !ok {
-		return nil, fmt.Errorf("missing '\\''")
+	if ok, _ := p.Require(isChar(')')); !ok {
+		return nil, p.errorf("missing ')'")
 	}
-	return &value, nil
+	p.pos++
+	return params, nil
 }
 
-func (p *ExprParser) parseLabel() (*tokenLabel, error) {
-	//fmt.Println(">>> label", p.residue())
-	var value tokenLabel
-	pstart := p.getPos()
+//parseLiteral 解析单引号或双引号包裹的字符串常量，其中的空白原样保留。
+//支持反斜杠转义\' \" \\ \n \t \r \uXXXX，引号也可以重复两次来转义，例如：
+//
+//	'don''t'
+func (p *ExprParser) parseLiteral() (*tokenLiteral, error) {
+	quote, err := p.current()
+	if err != nil || (quote != '\'' && quote != '"') {
+		return nil, p.errorf("missing quote")
+	}
+	start := p.pos
+	p.pos++
+	var sb strings.Builder
 	for {
-		ok, err := p.Require(func (ch byte) bool {
-			return unicode.IsLetter(rune(ch)) || unicode.IsNumber(rune(ch)) || ch == '_' || ch == '.'
-		})
-		if err != nil && !IsIterEnd(err){
+		if p.pos >= len(p.expr) {
+			p.pos = start
+			return nil, p.errorf("unterminated string")
+		}
+		ch, err := p.current()
+		if err != nil {
 			return nil, err
 		}
-		if !ok || IsIterEnd(err) {
-			break
+		switch {
+		case ch == quote:
+			if p.pos+1 < len(p.expr) && rune(p.expr[p.pos+1]) == quote {
+				sb.WriteRune(quote)
+				p.pos += 2
+				continue
+			}
+			p.pos++
+			value := tokenLiteral(sb.String())
+			return &value, nil
+		case ch == '\\':
+			if err := p.parseEscape(&sb); err != nil {
+				return nil, err
+			}
+		default:
+			sb.WriteRune(ch)
+			p.pos += utf8.RuneLen(ch)
 		}
-		p.Advance(1)
 	}
-	value = tokenLabel(p.expr[pstart:p.pos])
-	if len(value) == 0 {
-		return nil, fmt.Errorf("empty label")
+}
+
+//parseEscape 解析反斜杠开头的转义序列
+func (p *ExprParser) parseEscape(sb *strings.Builder) error {
+	if p.pos+1 >= len(p.expr) {
+		return p.errorf("unterminated escape sequence")
+	}
+	switch ch, _ := utf8.DecodeRuneInString(p.expr[p.pos+1:]); ch {
+	case '\'', '"', '\\':
+		sb.WriteRune(ch)
+	case 'n':
+		sb.WriteByte('\n')
+	case 't':
+		sb.WriteByte('\t')
+	case 'r':
+		sb.WriteByte('\r')
+	case 'u':
+		if p.pos+6 > len(p.expr) {
+			return p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(p.expr[p.pos+2:p.pos+6], 16, 32)
+		if err != nil {
+			return p.errorf("invalid unicode escape %q", p.expr[p.pos:p.pos+6])
+		}
+		sb.WriteRune(rune(code))
+		p.pos += 6
+		return nil
+	default:
+		return p.errorf("unknown escape sequence \\%c", ch)
 	}
-	//fmt.Printf("label: [%s]\n", value)
-	return &value, nil
+	p.pos += 2
+	return nil
 }
 
-func (p *ExprParser) parseParam() (*tokenParam, error) {
-	//fmt.Println(">>> param", p.residue())
-	var param tokenParam
-	ok, err := p.Expect(isChar('$'))
-	if err != nil {
-		return nil, err
+//parseNumber 解析十进制整数或小数，例如42、3.14
+func parseNumber(s string) (*tokenNumber, bool) {
+	if s == "" || s[0] < '0' || s[0] > '9' {
+		return nil, false
 	}
-	if !ok {
-		return nil, fmt.Errorf("missing '$'")
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return &tokenNumber{value: n}, true
 	}
-	pstart := p.getPos()
-	for {
-		ok, err := p.Require(func (ch byte) bool {
-			return unicode.IsLetter(rune(ch)) || unicode.IsNumber(rune(ch))
-		})
-		if err != nil {
-			return nil, err
+	for i := 0; i < len(s); i++ {
+		if (s[i] < '0' || s[i] > '9') && s[i] != '.' {
+			return nil, false
 		}
-		if !ok {
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return &tokenNumber{value: n}, true
+	}
+	return nil, false
+}
+
+//parseLabel 解析标签，例如name、menu.file.open、问候
+func (p *ExprParser) parseLabel() (*tokenLabel, error) {
+	start := p.pos
+	if ch, err := p.current(); err == nil && !isLabelStart(ch) {
+		return nil, p.errorf("empty label")
+	}
+	for p.pos < len(p.expr) {
+		ch, size := utf8.DecodeRuneInString(p.expr[p.pos:])
+		if !isLabelChar(ch) {
 			break
 		}
-		p.Advance(1)
+		p.pos += size
 	}
+	if p.pos == start {
+		return nil, p.errorf("empty label")
+	}
+	value := tokenLabel(p.expr[start:p.pos])
+	return &value, nil
+}
 
-	str := p.expr[pstart:p.pos]
-	index, err := strconv.Atoi(str)
+//parseParam 解析$N，或者块中绑定的变量，例如$item
+func (p *ExprParser) parseParam() (Expr, error) {
+	if ok, _ := p.Require(isChar('$')); !ok {
+		return nil, p.errorf("missing '$'")
+	}
+	p.pos++
+	if ch, err := p.current(); err == nil && isLabelStart(ch) && (ch < '0' || ch > '9') {
+		label, err := p.parseLabel()
+		if err != nil {
+			return nil, err
+		}
+		local := tokenLocal(*label)
+		return &local, nil
+	}
+	start := p.pos
+	for p.pos < len(p.expr) && p.expr[p.pos] >= '0' && p.expr[p.pos] <= '9' {
+		p.pos++
+	}
+	index, err := strconv.Atoi(p.expr[start:p.pos])
 	if err != nil {
-		return nil, err
+		p.pos = start - 1
+		return nil, p.errorf("invalid parameter")
 	}
-	param = tokenParam(index)
+	param := tokenParam(index)
 	return &param, nil
-
-}
\ No newline at end of file
+}
@@ -0,0 +1,114 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Placeholder 格式化字符串中的一个占位符或表达式
+type Placeholder struct {
+	Index        int    // 参数索引，省略时为-1
+	Indices      []int  // 有多个参数索引时为所有的索引，例如{0,1:range}为[0 1]，Index为第一个
+	HasFormatter bool   // 是否指定了格式化器（索引后面跟着':'）
+	Formatter    string // 格式化器标签或名字及其参数，例如{0:%.2f}中的%.2f、{0:money(USD, 2)}中的money(USD, 2)
+	Filters      string // 过滤器链，例如{0|upper|trunc(20)}中的upper|trunc(20)
+	IsExpr       bool   // 是否是{{}}包裹的表达式
+	Expr         string // 表达式的内容
+}
+
+//Label 返回格式化器的标签，没有使用格式化器时返回0
+func (p *Placeholder) Label() byte {
+	if len(p.Formatter) == 0 {
+		return 0
+	}
+	return p.Formatter[0]
+}
+
+//FormatterName 按name或name(args...)的形式拆分格式化器，例如{0:money(USD, 2)}返回money和USD, 2。
+//只检查写法，名字是否注册见LookupFormatter；以名字注册的格式化器优先于同名首字符的单字符标签
+func (p *Placeholder) FormatterName() (name string, args string, ok bool) {
+	return splitFormatterName(p.Formatter)
+}
+
+//Placeholders 按出现顺序返回格式化字符串中的占位符和表达式，格式化字符串有语法错误时返回错误
+//与Fmt使用相同的解析规则，表达式的内容不在这里解析，见References；
+//{{#each}}块的标签在这里检查，开始标签有错误、{{else}}和{{/each}}不在块中以及块没有结束时返回错误
+func Placeholders(pattern string) ([]Placeholder, error) {
+	var placeholders []Placeholder
+	depth := 0 // 未结束的{{#each}}块数
+	iter := NewFormatIter(pattern)
+	lastState := FORMAT_STATE_START
+	current := Placeholder{Index: -1}
+	for {
+		state, token, err := iter.NextToken()
+		if err != nil && !IsIterEnd(err) {
+			return placeholders, err
+		}
+		switch lastState {
+		case FORMAT_STATE_PARSE_INDEX:
+			indices, err := parseIndices(token)
+			if err != nil {
+				return placeholders, fmt.Errorf("%v at column %d", err, iter.Column()-1)
+			}
+			current.Index = indices[0]
+			if len(indices) > 1 {
+				current.Indices = indices
+			}
+		case FORMAT_STATE_PARSE_FORMATTER:
+			current.HasFormatter = true
+			current.Formatter = token
+		case FORMAT_STATE_PARSE_FILTER:
+			current.Filters = token
+		case FORMAT_STATE_EXPR:
+			current.IsExpr = true
+			current.Expr = token
+			if err := checkBlockTag(token, &depth); err != nil {
+				return placeholders, fmt.Errorf("%v at column %d", err, iter.Column()-1)
+			}
+		}
+		if IsIterEnd(err) {
+			switch lastState {
+			case FORMAT_STATE_START, FORMAT_STATE_LITERAL, FORMAT_STATE_PLACEHOLDER_END:
+				if depth > 0 {
+					return placeholders, fmt.Errorf("each: missing {{%s}}", EACH_BLOCK_END)
+				}
+				return placeholders, nil
+			}
+			return placeholders, fmt.Errorf("unterminated placeholder at column %d", iter.Column())
+		}
+		if state == FORMAT_STATE_END {
+			// 参数索引中出现了数字、':'、'}'以外的字符
+			return placeholders, fmt.Errorf("invalid character in placeholder index at column %d: %s", iter.Column()-1, token)
+		}
+		if state == FORMAT_STATE_PLACEHOLDER_END {
+			placeholders = append(placeholders, current)
+			current = Placeholder{Index: -1}
+		}
+		lastState = state
+	}
+}
+
+//checkBlockTag 检查块的标签，depth为未结束的块数
+func checkBlockTag(token string, depth *int) error {
+	_, isBlock, err := env.exprFormatterConfig.parseBlockTag(token)
+	if !isBlock {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("invalid block tag {{%s}}: %w", strings.TrimSpace(token), err)
+	}
+	switch tag := strings.TrimSpace(token); tag {
+	case EACH_BLOCK_END:
+		if *depth == 0 {
+			return fmt.Errorf("unexpected {{%s}}", tag)
+		}
+		(*depth)--
+	case EACH_BLOCK_ELSE:
+		if *depth == 0 {
+			return fmt.Errorf("unexpected {{%s}}", tag)
+		}
+	default:
+		(*depth)++
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package format
+
+import "fmt"
+
+const (
+	//RANGE_FORMATTER_NAME 输出两个参数组成的范围：{0,1:range} => 3–5，两端相等时只输出一个值，{0,1:range(' to ')}指定分隔符
+	RANGE_FORMATTER_NAME = "range"
+	//RATIO_FORMATTER_NAME 输出两个参数的比，整数按最大公约数约分：{0,1:ratio} => 16:9，{0,1:ratio('/')}指定分隔符
+	RATIO_FORMATTER_NAME = "ratio"
+)
+
+var rangeParams = []FormatterParam{
+	{Name: "sep", Kind: VALUE_STRING, Optional: true, Default: StringValue("–"), Doc: "separator between the two ends"},
+}
+
+var ratioParams = []FormatterParam{
+	{Name: "sep", Kind: VALUE_STRING, Optional: true, Default: StringValue(":"), Doc: "separator between the two terms"},
+}
+
+type RangeFormatter struct {
+	sep string
+}
+
+func NewRangeFormatter() IValueFormatter {
+	return &RangeFormatter{sep: "–"}
+}
+
+func (f *RangeFormatter) Parse(token string) (err error) {
+	if token != "" {
+		f.sep = token
+	}
+	return
+}
+
+func (f *RangeFormatter) ParseArgs(args FormatterArgs) error {
+	f.sep = args["sep"].String()
+	return nil
+}
+
+func (f *RangeFormatter) Format(value any) string {
+	return fmt.Sprintf("%v", value)
+}
+
+func (f *RangeFormatter) FormatArgs(args []any) string {
+	if Equal(ToValue(args[0]), ToValue(args[1])) {
+		return fmt.Sprintf("%v", args[0])
+	}
+	return fmt.Sprintf("%v", args[0]) + f.sep + fmt.Sprintf("%v", args[1])
+}
+
+func (f *RangeFormatter) ArgCount() int {
+	return 2
+}
+
+type RatioFormatter struct {
+	sep string
+}
+
+func NewRatioFormatter() IValueFormatter {
+	return &RatioFormatter{sep: ":"}
+}
+
+func (f *RatioFormatter) Parse(token string) (err error) {
+	if token != "" {
+		f.sep = token
+	}
+	return
+}
+
+func (f *RatioFormatter) ParseArgs(args FormatterArgs) error {
+	f.sep = args["sep"].String()
+	return nil
+}
+
+func (f *RatioFormatter) Format(value any) string {
+	return fmt.Sprintf("%v", value)
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func (f *RatioFormatter) FormatArgs(args []any) string {
+	a, aok := ToValue(args[0]).Int()
+	b, bok := ToValue(args[1]).Int()
+	if aok && bok {
+		if d := gcd(a, b); d > 1 {
+			a, b = a/d, b/d
+		}
+		return fmt.Sprintf("%d%s%d", a, f.sep, b)
+	}
+	return fmt.Sprintf("%v", args[0]) + f.sep + fmt.Sprintf("%v", args[1])
+}
+
+func (f *RatioFormatter) ArgCount() int {
+	return 2
+}
@@ -0,0 +1,159 @@
+package format
+
+//Reference 格式化字符串中对表达式解释器的一次引用
+type Reference struct {
+	Namespace string // 命名空间，省略时为空（使用默认解释器）
+	Key       string // 变量名或函数名
+	IsFunc    bool   // 是否是函数调用
+	ArgCount  int    // 函数调用的实参个数，变量为0
+	Params    []int  // 函数调用的实参引用的参数索引，包括嵌套的引用，例如greet($0, upper($2))为[0, 2]
+}
+
+//References 解析格式化字符串，按出现顺序返回其中所有表达式引用的变量和函数
+//例如References("{{Lang::hello + greet($0)}}")返回Lang::hello和greet(1个实参)
+func References(pattern string) ([]Reference, error) {
+	var refs []Reference
+	err := walkExprs(pattern, func(ex Expr) {
+		refs = collectReferences(ex, refs)
+	})
+	return refs, err
+}
+
+//ParamIndices 解析格式化字符串，按出现顺序返回其中所有表达式引用的$N的参数索引，
+//包括不在函数调用中的$N，例如ParamIndices("{{ $3 }} {{greet(upper($2))}}")返回[3, 2]
+func ParamIndices(pattern string) ([]int, error) {
+	var params []int
+	err := walkExprs(pattern, func(ex Expr) {
+		params = collectParams(ex, params)
+	})
+	return params, err
+}
+
+//walkExprs 解析格式化字符串中的表达式，块标签的每个参数分别调用fn
+func walkExprs(pattern string, fn func(ex Expr)) error {
+	iter := NewFormatIter(pattern)
+	lastState := FORMAT_STATE_START
+	for {
+		state, token, err := iter.NextToken()
+		if err != nil && !IsIterEnd(err) {
+			return err
+		}
+		if lastState == FORMAT_STATE_EXPR {
+			params, isBlock, perr := env.exprFormatterConfig.parseBlockTag(token)
+			if !isBlock {
+				var ex Expr
+				ex, perr = NewExprParser(token).ParseExpr()
+				params = []Expr{ex}
+			}
+			if perr != nil {
+				return perr
+			}
+			for _, ex := range params {
+				fn(ex)
+			}
+		}
+		lastState = state
+		if IsIterEnd(err) {
+			return nil
+		}
+	}
+}
+
+//collectReferences 收集表达式中的引用，解析器在表达式不完整时可能返回值为nil的节点
+func collectReferences(ex Expr, refs []Reference) []Reference {
+	switch e := ex.(type) {
+	case *tokenVar:
+		if e == nil {
+			return refs
+		}
+		refs = append(refs, Reference{Namespace: string(e.namespace), Key: string(e.key)})
+	case *tokenFunc:
+		if e == nil {
+			return refs
+		}
+		ref := Reference{Namespace: string(e.namespace), Key: string(e.key), IsFunc: true, ArgCount: len(e.params)}
+		for _, param := range e.params {
+			ref.Params = collectParams(param, ref.Params)
+		}
+		refs = append(refs, ref)
+		// 实参中嵌套的引用排在函数本身之后
+		for _, param := range e.params {
+			refs = collectReferences(param, refs)
+		}
+	case *binaryExpr:
+		if e == nil {
+			return refs
+		}
+		refs = collectReferences(e.left, refs)
+		refs = collectReferences(e.right, refs)
+	case *unaryExpr:
+		refs = collectReferences(e.operand, refs)
+	case *logicalExpr:
+		refs = collectReferences(e.left, refs)
+		refs = collectReferences(e.right, refs)
+	case *includeExpr:
+		// 模板不是消息，只收集实参中的引用
+		for _, param := range e.params {
+			refs = collectReferences(param, refs)
+		}
+	case *pipeExpr:
+		refs = collectReferences(e.value, refs)
+		for _, param := range e.filter.params {
+			refs = collectReferences(param, refs)
+		}
+	case *ternaryExpr:
+		refs = collectReferences(e.cond, refs)
+		refs = collectReferences(e.then, refs)
+		refs = collectReferences(e.els, refs)
+	case *coalesceExpr:
+		refs = collectReferences(e.left, refs)
+		refs = collectReferences(e.right, refs)
+	}
+	return refs
+}
+
+//collectParams 收集表达式中引用的$N的参数索引
+func collectParams(ex Expr, params []int) []int {
+	switch e := ex.(type) {
+	case *tokenParam:
+		if e == nil {
+			return params
+		}
+		params = append(params, int(*e))
+	case *tokenFunc:
+		if e == nil {
+			return params
+		}
+		for _, param := range e.params {
+			params = collectParams(param, params)
+		}
+	case *binaryExpr:
+		if e == nil {
+			return params
+		}
+		params = collectParams(e.left, params)
+		params = collectParams(e.right, params)
+	case *unaryExpr:
+		params = collectParams(e.operand, params)
+	case *logicalExpr:
+		params = collectParams(e.left, params)
+		params = collectParams(e.right, params)
+	case *includeExpr:
+		for _, param := range e.params {
+			params = collectParams(param, params)
+		}
+	case *pipeExpr:
+		params = collectParams(e.value, params)
+		for _, param := range e.filter.params {
+			params = collectParams(param, params)
+		}
+	case *ternaryExpr:
+		params = collectParams(e.cond, params)
+		params = collectParams(e.then, params)
+		params = collectParams(e.els, params)
+	case *coalesceExpr:
+		params = collectParams(e.left, params)
+		params = collectParams(e.right, params)
+	}
+	return params
+}
@@ -1,11 +1,16 @@
 package format
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 const STD_FORMATTER_LABEL = '%'
 
+//StdFormatter 与fmt.Printf类似，格式中的*从参数读取宽度或精度：{:%*d}使用两个参数，第一个是宽度
 type StdFormatter struct {
 	formatter string
+	argCount int
 }
 
 func NewStdFormatter() IValueFormatter {
@@ -14,6 +19,7 @@ func NewStdFormatter() IValueFormatter {
 
 func (f *StdFormatter) Parse(token string) (err error) {
 	f.formatter = token
+	f.argCount = 1 + strings.Count(token, "*")
 	//fmt.Println("formatter", f.formatter)
 	return
 }
@@ -22,6 +28,10 @@ func (f *StdFormatter) Format(value any) string {
 	return fmt.Sprintf("%" + f.formatter, value)
 }
 
+func (f *StdFormatter) FormatArgs(args []any) string {
+	return fmt.Sprintf("%" + f.formatter, args...)
+}
+
 func (f *StdFormatter) ArgCount() int {
-	return 1
+	return f.argCount
 }
\ No newline at end of file
@@ -0,0 +1,113 @@
+package format
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+//TEMPLATE_NAMESPACE 模板的命名空间，{{tpl::footer($0)}}插入模板footer，不能再注册为解释器
+const TEMPLATE_NAMESPACE = "tpl"
+
+//DEFAULT_MAX_INCLUDE_DEPTH 默认的模板最大嵌套层数
+const DEFAULT_MAX_INCLUDE_DEPTH = 16
+
+var (
+	//ErrTemplateCycle 模板直接或间接地插入了自己
+	ErrTemplateCycle = errors.New("template cycle")
+	//ErrIncludeDepth 模板嵌套插入的层数超过了MaxIncludeDepth
+	ErrIncludeDepth = errors.New("template include depth exceeded")
+)
+
+//Define 定义模板，已存在的模板会被替换。模板是格式化字符串，可以插入其他模板
+//@params name 模板的名字，可以包含字母、数字、下划线和.; pattern 格式化字符串，有语法错误时返回错误
+func (f *ExprFormatterConfig) Define(name string, pattern string) error {
+	if !isLabel(name) {
+		return fmt.Errorf("invalid template name: %q", name)
+	}
+	if _, err := Placeholders(pattern); err != nil {
+		return fmt.Errorf("template %s: %w", name, err)
+	}
+	f.Templates[name] = pattern
+	return nil
+}
+
+//isLabel 字符串是否是合法的标签
+func isLabel(s string) bool {
+	if s == "" || !utf8.ValidString(s) {
+		return false
+	}
+	for i, ch := range s {
+		if !isLabelChar(ch) || (i == 0 && !isLabelStart(ch)) {
+			return false
+		}
+	}
+	return true
+}
+
+//include 使用args渲染模板，模板中的表达式与外层共享配置。
+//模板中求值失败的表达式与Fmt一样不输出，只有循环插入和超过最大层数作为错误返回
+func (f *ExprFormatter) include(name string, args []any) (Value, error) {
+	pattern, ok := f.Templates[name]
+	if !ok {
+		err := fmt.Errorf("%w: template %s", ErrKeyNotFound, name)
+		if f.coalescing == 0 {
+			return f.missingKey(TEMPLATE_NAMESPACE, name, args, err)
+		}
+		return Nil, err
+	}
+	str, err := f.renderNested(name, pattern, args)
+	if isIncludeErr(err) {
+		return Nil, err
+	}
+	return StringValue(str), nil
+}
+
+//renderNested 使用args渲染嵌套的格式化字符串，name用于检测循环插入
+func (f *ExprFormatter) renderNested(name string, pattern string, args []any) (string, error) {
+	if slices.Contains(f.includes, name) {
+		return "", fmt.Errorf("%w: %s -> %s", ErrTemplateCycle, strings.Join(f.includes, " -> "), name)
+	}
+	if len(f.includes) >= f.MaxIncludeDepth {
+		return "", fmt.Errorf("%w: %s at depth %d", ErrIncludeDepth, name, f.MaxIncludeDepth)
+	}
+	child := f.child(args)
+	child.includes = append(child.includes, name)
+	return render(pattern, child)
+}
+
+//isIncludeErr 是否是循环插入或超过最大层数的错误
+func isIncludeErr(err error) bool {
+	return errors.Is(err, ErrTemplateCycle) || errors.Is(err, ErrIncludeDepth)
+}
+
+//evalPattern 在当前的求值环境中渲染解释器返回的格式化字符串，name用于检测循环插入，例如namespace::key。
+//与include一样，求值失败的表达式不输出，只有循环插入和超过最大层数作为错误返回
+func (f *ExprFormatter) evalPattern(interpreter IPatternInterpreter, name string, key string, args []any) (Value, error) {
+	pattern, err := interpreter.Pattern(key)
+	if err != nil {
+		return Nil, err
+	}
+	str, err := f.renderNested(name, pattern, args)
+	if isIncludeErr(err) {
+		return Nil, err
+	}
+	return StringValue(str), nil
+}
+
+//FormatPattern 使用默认配置渲染解释器中键对应的格式化字符串，同时返回求值失败的表达式的错误，
+//供实现IPatternInterpreter的解释器的Format使用。
+//内层表达式的错误不是该键的错误，除循环插入和超过最大层数外不再包装，例如内层缺失的键不会让外层的键按缺失处理
+func FormatPattern(interpreter IPatternInterpreter, key string, args []any) (string, error) {
+	pattern, err := interpreter.Pattern(key)
+	if err != nil {
+		return "", err
+	}
+	str, err := NewExprFormatter(env.exprFormatterConfig).renderNested(key, pattern, args)
+	if err != nil && !isIncludeErr(err) {
+		return "", fmt.Errorf("%s: %s", key, err)
+	}
+	return str, err
+}
@@ -9,6 +9,7 @@ import (
 
 const (
 	TIME_FORMATTER_LABEL  = '@'
+	TIME_FORMATTER_NAME   = "time"
 	TIME_FORMATTER_YMDHMS = "2006-01-02 15:04:05"
 	TIME_FORMATTER_YMD    = "2006-01-02"
 	TIME_FORMATTER_HMS    = "15:04:05"
@@ -0,0 +1,159 @@
+package format
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+//KeyUsage 一个键的查找统计，不同语言分别统计
+type KeyUsage struct {
+	Namespace string        `json:"namespace"`
+	Key       string        `json:"key"`
+	Locale    string        `json:"locale,omitempty"`
+	Hits      int64         `json:"hits"`
+	Misses    int64         `json:"misses"` // 键或命名空间不存在
+	Errors    int64         `json:"errors"` // 其他错误
+	Total     time.Duration `json:"total_ns"`
+	LastMiss  time.Time     `json:"last_miss,omitzero"`
+}
+
+//FormatterUsage 一个格式化器的调用统计
+type FormatterUsage struct {
+	Label string        `json:"label"` // 单字符标签或命名格式化器的名字
+	Calls int64         `json:"calls"`
+	Total time.Duration `json:"total_ns"`
+}
+
+//UsageSnapshot 某一时刻的统计结果
+type UsageSnapshot struct {
+	Since      time.Time        `json:"since"`
+	Keys       []KeyUsage       `json:"keys"`
+	Formatters []FormatterUsage `json:"formatters"`
+}
+
+//Missing 返回查找失败过的键
+func (s *UsageSnapshot) Missing() []KeyUsage {
+	var missing []KeyUsage
+	for _, k := range s.Keys {
+		if k.Misses > 0 {
+			missing = append(missing, k)
+		}
+	}
+	return missing
+}
+
+type usageKey struct {
+	namespace, key, locale string
+}
+
+//UsageCounter 在内存中统计键和格式化器使用情况的观察者
+//counter := NewUsageCounter()
+//AddObserver(counter)
+//counter.Snapshot().Missing()
+//通过expvar发布或者作为调试接口见usagehttp包
+type UsageCounter struct {
+	mu         sync.Mutex
+	since      time.Time
+	keys       map[usageKey]*KeyUsage
+	formatters map[string]*FormatterUsage
+}
+
+func NewUsageCounter() *UsageCounter {
+	c := &UsageCounter{}
+	c.Reset()
+	return c
+}
+
+//Reset 清空统计
+func (c *UsageCounter) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.since = time.Now()
+	c.keys = make(map[usageKey]*KeyUsage)
+	c.formatters = make(map[string]*FormatterUsage)
+}
+
+func (c *UsageCounter) OnLookup(e LookupEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := usageKey{e.Namespace, e.Key, e.Locale}
+	u, ok := c.keys[k]
+	if !ok {
+		u = &KeyUsage{Namespace: e.Namespace, Key: e.Key, Locale: e.Locale}
+		c.keys[k] = u
+	}
+	switch {
+	case e.Hit:
+		u.Hits++
+	case e.Missing():
+		u.Misses++
+		u.LastMiss = time.Now()
+	default:
+		u.Errors++
+	}
+	u.Total += e.Duration
+}
+
+func (c *UsageCounter) OnFormat(e FormatterEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	label := e.Name
+	if label == "" {
+		label = string(e.Label)
+		if e.Label == 0 {
+			label = "default"
+		}
+	}
+	u, ok := c.formatters[label]
+	if !ok {
+		u = &FormatterUsage{Label: label}
+		c.formatters[label] = u
+	}
+	u.Calls++
+	u.Total += e.Duration
+}
+
+//Snapshot 返回当前的统计结果，按命名空间、键和语言排序
+func (c *UsageCounter) Snapshot() UsageSnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := UsageSnapshot{Since: c.since}
+	for _, u := range c.keys {
+		s.Keys = append(s.Keys, *u)
+	}
+	sort.Slice(s.Keys, func(i, j int) bool {
+		a, b := s.Keys[i], s.Keys[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.Locale < b.Locale
+	})
+	for _, u := range c.formatters {
+		s.Formatters = append(s.Formatters, *u)
+	}
+	sort.Slice(s.Formatters, func(i, j int) bool { return s.Formatters[i].Label < s.Formatters[j].Label })
+	return s
+}
+
+//Unused 返回keys中从未被成功查找过的键，keys通常来自消息包或翻译目录
+func (c *UsageCounter) Unused(namespace string, keys []string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	used := make(map[string]bool)
+	for k, u := range c.keys {
+		if k.namespace == namespace && u.Hits > 0 {
+			used[k.key] = true
+		}
+	}
+	var unused []string
+	for _, key := range keys {
+		if !used[key] {
+			unused = append(unused, key)
+		}
+	}
+	return unused
+}
@@ -0,0 +1,33 @@
+//usagehttp 通过expvar和HTTP发布format.UsageCounter的统计结果。
+//导入expvar会在http.DefaultServeMux上注册/debug/vars，所以通过expvar和HTTP发布统计结果的部分放在单独的包中，
+//只有导入该包的程序才会暴露这些接口：
+//counter := format.NewUsageCounter()
+//format.AddObserver(counter)
+//usagehttp.Publish("khutils_usage", counter)          // 在/debug/vars中查看
+//http.Handle("/debug/i18n", usagehttp.Handler(counter)) // 或者单独的调试接口
+package usagehttp
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+
+	"github.com/Khellendros97/khutils/format"
+)
+
+//Publish 将统计结果以name发布到expvar
+func Publish(name string, counter *format.UsageCounter) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return counter.Snapshot()
+	}))
+}
+
+//Handler 返回以JSON格式输出统计结果的调试接口
+func Handler(counter *format.UsageCounter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(counter.Snapshot())
+	})
+}
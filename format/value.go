@@ -0,0 +1,306 @@
+package format
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//ValueKind 表达式值的类型
+type ValueKind int
+
+const (
+	VALUE_NIL    ValueKind = iota
+	VALUE_STRING           // 字符串
+	VALUE_NUMBER           // 整数或浮点数
+	VALUE_BOOL             // 布尔值
+	VALUE_TIME             // time.Time
+	VALUE_LIST             // 切片或数组
+	VALUE_MAP              // 键为字符串的map
+	VALUE_OPAQUE           // 其他类型，只能传递和输出
+)
+
+//VALUE_ANY 注册操作符时表示匹配任意类型的操作数
+const VALUE_ANY ValueKind = -1
+
+func (k ValueKind) String() string {
+	switch k {
+	case VALUE_NIL:
+		return "nil"
+	case VALUE_STRING:
+		return "string"
+	case VALUE_NUMBER:
+		return "number"
+	case VALUE_BOOL:
+		return "bool"
+	case VALUE_TIME:
+		return "time"
+	case VALUE_LIST:
+		return "list"
+	case VALUE_MAP:
+		return "map"
+	case VALUE_OPAQUE:
+		return "opaque"
+	case VALUE_ANY:
+		return "any"
+	default:
+		return "unknown"
+	}
+}
+
+//Value 表达式求值的结果，保存原始的Go值，只在最终写入输出时转换为字符串
+type Value struct {
+	kind ValueKind
+	raw  any
+}
+
+//Nil 空值
+var Nil = Value{}
+
+//StringValue 创建字符串值
+func StringValue(s string) Value {
+	return Value{kind: VALUE_STRING, raw: s}
+}
+
+//NumberValue 创建数值，n可以是任意整数或浮点数类型
+func NumberValue(n any) Value {
+	return Value{kind: VALUE_NUMBER, raw: n}
+}
+
+//BoolValue 创建布尔值
+func BoolValue(b bool) Value {
+	return Value{kind: VALUE_BOOL, raw: b}
+}
+
+//ListValue 创建列表值
+func ListValue(items []Value) Value {
+	return Value{kind: VALUE_LIST, raw: items}
+}
+
+//ToValue 将Go值转换为表达式的值：字符串、数值、布尔值、time.Time、切片/数组、键为字符串的map，
+//其他类型作为VALUE_OPAQUE保存。Value本身原样返回
+func ToValue(v any) Value {
+	switch x := v.(type) {
+	case nil:
+		return Nil
+	case Value:
+		return x
+	case string:
+		return StringValue(x)
+	case bool:
+		return BoolValue(x)
+	case time.Time:
+		return Value{kind: VALUE_TIME, raw: x}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return NumberValue(x)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return Value{kind: VALUE_STRING, raw: v}
+	case reflect.Bool:
+		return Value{kind: VALUE_BOOL, raw: v}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return Value{kind: VALUE_NUMBER, raw: v}
+	case reflect.Slice, reflect.Array:
+		return Value{kind: VALUE_LIST, raw: v}
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			return Value{kind: VALUE_MAP, raw: v}
+		}
+	}
+	return Value{kind: VALUE_OPAQUE, raw: v}
+}
+
+//Kind 返回值的类型
+func (v Value) Kind() ValueKind {
+	return v.kind
+}
+
+//IsNil 是否为空值
+func (v Value) IsNil() bool {
+	return v.kind == VALUE_NIL
+}
+
+//Interface 返回原始的Go值，传给解释器的实参使用它
+func (v Value) Interface() any {
+	if items, ok := v.raw.([]Value); ok {
+		list := make([]any, len(items))
+		for i, item := range items {
+			list[i] = item.Interface()
+		}
+		return list
+	}
+	return v.raw
+}
+
+//String 转换为输出的字符串，与fmt.Sprintf("%v")一致，空值输出空字符串
+func (v Value) String() string {
+	switch v.kind {
+	case VALUE_NIL:
+		return ""
+	case VALUE_STRING:
+		if s, ok := v.raw.(string); ok {
+			return s
+		}
+	}
+	return fmt.Sprintf("%v", v.Interface())
+}
+
+//Float 返回数值，不是数值时第二个返回值为false
+func (v Value) Float() (float64, bool) {
+	if v.kind != VALUE_NUMBER {
+		return 0, false
+	}
+	rv := reflect.ValueOf(v.raw)
+	switch {
+	case rv.CanInt():
+		return float64(rv.Int()), true
+	case rv.CanUint():
+		return float64(rv.Uint()), true
+	case rv.CanFloat():
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+//Int 返回整数值，值是浮点数或超出int64范围时第二个返回值为false
+func (v Value) Int() (int64, bool) {
+	if v.kind != VALUE_NUMBER {
+		return 0, false
+	}
+	rv := reflect.ValueOf(v.raw)
+	switch {
+	case rv.CanInt():
+		return rv.Int(), true
+	case rv.CanUint():
+		if n := rv.Uint(); n <= 1<<63-1 {
+			return int64(n), true
+		}
+	}
+	return 0, false
+}
+
+//Bool 返回布尔值，不是布尔值时第二个返回值为false
+func (v Value) Bool() (bool, bool) {
+	if v.kind != VALUE_BOOL {
+		return false, false
+	}
+	return reflect.ValueOf(v.raw).Bool(), true
+}
+
+//Time 返回时间，不是时间时第二个返回值为false
+func (v Value) Time() (time.Time, bool) {
+	t, ok := v.raw.(time.Time)
+	return t, ok
+}
+
+//List 返回列表的元素，不是列表时返回nil
+func (v Value) List() []Value {
+	if v.kind != VALUE_LIST {
+		return nil
+	}
+	if items, ok := v.raw.([]Value); ok {
+		return items
+	}
+	rv := reflect.ValueOf(v.raw)
+	items := make([]Value, rv.Len())
+	for i := range items {
+		items[i] = ToValue(rv.Index(i).Interface())
+	}
+	return items
+}
+
+//Map 返回map的元素，不是map时返回nil
+func (v Value) Map() map[string]Value {
+	if v.kind != VALUE_MAP {
+		return nil
+	}
+	rv := reflect.ValueOf(v.raw)
+	items := make(map[string]Value, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		items[iter.Key().String()] = ToValue(iter.Value().Interface())
+	}
+	return items
+}
+
+//Truthy 值的真假，用于条件表达式和!、&&、||：nil、false、0、空字符串、空列表、空map、零时间和nil指针为假，其他为真
+func (v Value) Truthy() bool {
+	switch v.kind {
+	case VALUE_NIL:
+		return false
+	case VALUE_BOOL:
+		b, _ := v.Bool()
+		return b
+	case VALUE_NUMBER:
+		n, _ := v.Float()
+		return n != 0
+	case VALUE_STRING:
+		return v.String() != ""
+	case VALUE_TIME:
+		t, _ := v.Time()
+		return !t.IsZero()
+	case VALUE_LIST, VALUE_MAP:
+		if items, ok := v.raw.([]Value); ok {
+			return len(items) > 0
+		}
+		return reflect.ValueOf(v.raw).Len() > 0
+	}
+	rv := reflect.ValueOf(v.raw)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Func, reflect.Chan:
+		return !rv.IsNil()
+	}
+	return true
+}
+
+//asNumber 数值，或者可以解析为数值的字符串
+func asNumber(v Value) (float64, bool) {
+	if v.kind == VALUE_STRING {
+		n, err := strconv.ParseFloat(strings.TrimSpace(v.String()), 64)
+		return n, err == nil
+	}
+	return v.Float()
+}
+
+//Compare 比较两个值，返回-1、0或1：数值按大小，字符串按字典序，时间按先后；
+//一侧是数值时，另一侧的字符串解析为数值后比较，例如解释器返回的"3"与3相等。其他组合返回错误
+func Compare(a, b Value) (int, error) {
+	switch {
+	case a.kind == VALUE_NUMBER || b.kind == VALUE_NUMBER:
+		if x, ok := a.Int(); ok {
+			if y, ok := b.Int(); ok {
+				return cmp.Compare(x, y), nil
+			}
+		}
+		x, okx := asNumber(a)
+		y, oky := asNumber(b)
+		if okx && oky {
+			return cmp.Compare(x, y), nil
+		}
+	case a.kind == VALUE_STRING && b.kind == VALUE_STRING:
+		return strings.Compare(a.String(), b.String()), nil
+	case a.kind == VALUE_TIME && b.kind == VALUE_TIME:
+		x, _ := a.Time()
+		y, _ := b.Time()
+		return x.Compare(y), nil
+	}
+	return 0, fmt.Errorf("can not compare %s and %s", a.kind, b.kind)
+}
+
+//Equal 判断两个值是否相等，可以用Compare比较的值按Compare的结果，其他值类型相同且深度相等时相等
+func Equal(a, b Value) bool {
+	if n, err := Compare(a, b); err == nil {
+		return n == 0
+	}
+	if a.kind != b.kind {
+		return false
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
@@ -0,0 +1,207 @@
+package gettext
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//contextSeparator msgctxt与msgid之间的分隔符，与.mo文件中的约定一致
+const contextSeparator = "\x04"
+
+//Message 翻译目录中的一个条目
+type Message struct {
+	Context           string   // msgctxt
+	ID                string   // msgid
+	IDPlural          string   // msgid_plural
+	Str               []string // msgstr，含复数形式时按下标保存msgstr[n]
+	Fuzzy             bool     // 是否带有fuzzy标记
+	Flags             []string // #, 标记（不含fuzzy）
+	Comments          []string // #  译者注释
+	ExtractedComments []string // #. 提取的注释
+	References        []string // #: 源码位置
+}
+
+//Translated 条目是否已翻译
+func (m *Message) Translated() bool {
+	for _, s := range m.Str {
+		if s != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func messageKey(context, id string) string {
+	if context == "" {
+		return id
+	}
+	return context + contextSeparator + id
+}
+
+//Catalog 一个语言的翻译目录，对应一个.po或.mo文件
+type Catalog struct {
+	Header   map[string]string // 头部条目（msgid ""）中的键值对
+	UseFuzzy bool              // 查找时是否使用fuzzy条目，默认与msgfmt一致忽略它们
+
+	headerKeys []string // 头部键值对的原始顺序
+	nplurals   int
+	plural     PluralFunc
+	messages   map[string]*Message
+	order      []string
+}
+
+func NewCatalog() *Catalog {
+	return &Catalog{
+		Header:   make(map[string]string),
+		nplurals: 2,
+		plural:   defaultPlural,
+		messages: make(map[string]*Message),
+	}
+}
+
+//Language 返回头部声明的语言
+func (c *Catalog) Language() string {
+	return c.Header["Language"]
+}
+
+//NPlurals 返回复数形式的数量
+func (c *Catalog) NPlurals() int {
+	return c.nplurals
+}
+
+//SetHeader 解析头部条目的内容，并根据Plural-Forms更新复数规则
+func (c *Catalog) SetHeader(header string) error {
+	for _, line := range strings.Split(header, "\n") {
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(kv[0])
+		if _, ok := c.Header[key]; !ok {
+			c.headerKeys = append(c.headerKeys, key)
+		}
+		c.Header[key] = strings.TrimSpace(kv[1])
+	}
+	if pf, ok := c.Header["Plural-Forms"]; ok {
+		nplurals, fn, err := ParsePluralForms(pf)
+		if err != nil {
+			return err
+		}
+		c.nplurals, c.plural = nplurals, fn
+	}
+	return nil
+}
+
+//HeaderString 按原始顺序拼接头部条目的内容
+func (c *Catalog) HeaderString() string {
+	var sb strings.Builder
+	written := make(map[string]bool)
+	for _, key := range c.headerKeys {
+		if value, ok := c.Header[key]; ok {
+			sb.WriteString(key + ": " + value + "\n")
+			written[key] = true
+		}
+	}
+	// 直接修改Header添加的键没有顺序，按字母顺序排在最后
+	var extra []string
+	for key := range c.Header {
+		if !written[key] {
+			extra = append(extra, key)
+		}
+	}
+	sort.Strings(extra)
+	for _, key := range extra {
+		sb.WriteString(key + ": " + c.Header[key] + "\n")
+	}
+	return sb.String()
+}
+
+//Add 添加一个条目，msgid为空的条目作为头部解析
+func (c *Catalog) Add(m *Message) error {
+	if m.ID == "" && m.Context == "" {
+		if len(m.Str) == 0 {
+			return nil
+		}
+		return c.SetHeader(m.Str[0])
+	}
+	key := messageKey(m.Context, m.ID)
+	if _, ok := c.messages[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.messages[key] = m
+	return nil
+}
+
+//Lookup 查找条目，不存在或（未启用UseFuzzy时）为fuzzy条目时返回nil
+func (c *Catalog) Lookup(context, id string) *Message {
+	m := c.messages[messageKey(context, id)]
+	if m == nil || (m.Fuzzy && !c.UseFuzzy) || !m.Translated() {
+		return nil
+	}
+	return m
+}
+
+//Messages 按添加顺序返回所有条目（包括fuzzy条目）
+func (c *Catalog) Messages() []*Message {
+	messages := make([]*Message, 0, len(c.order))
+	for _, key := range c.order {
+		messages = append(messages, c.messages[key])
+	}
+	return messages
+}
+
+//PluralIndex 返回数量n对应的复数形式下标
+func (c *Catalog) PluralIndex(n int) int {
+	index := c.plural(n)
+	if index < 0 || index >= c.nplurals {
+		return 0
+	}
+	return index
+}
+
+//Gettext 翻译msgid，未翻译时返回msgid本身
+func (c *Catalog) Gettext(id string) string {
+	return c.PGettext("", id)
+}
+
+//PGettext 翻译带上下文的msgid
+func (c *Catalog) PGettext(context, id string) string {
+	m := c.Lookup(context, id)
+	if m == nil {
+		return id
+	}
+	return m.Str[0]
+}
+
+//NGettext 根据数量n翻译含复数形式的msgid
+func (c *Catalog) NGettext(id, idPlural string, n int) string {
+	return c.NPGettext("", id, idPlural, n)
+}
+
+//NPGettext 根据数量n翻译带上下文、含复数形式的msgid
+func (c *Catalog) NPGettext(context, id, idPlural string, n int) string {
+	m := c.Lookup(context, id)
+	if m == nil {
+		if n == 1 {
+			return id
+		}
+		return idPlural
+	}
+	return c.pluralStr(m, n)
+}
+
+func (c *Catalog) pluralStr(m *Message, n int) string {
+	if m.IDPlural == "" {
+		return m.Str[0]
+	}
+	index := c.PluralIndex(n)
+	if index >= len(m.Str) || m.Str[index] == "" {
+		return m.Str[0]
+	}
+	return m.Str[index]
+}
+
+func (c *Catalog) String() string {
+	return fmt.Sprintf("Catalog(%s, %d messages)", c.Language(), len(c.messages))
+}
@@ -0,0 +1,102 @@
+package gettext
+
+import (
+	"strings"
+
+	"github.com/Khellendros97/khutils/catalog"
+	"github.com/Khellendros97/khutils/plural"
+)
+
+//maxPluralSample 推断复数形式对应的CLDR类别时尝试的最大数量
+const maxPluralSample = 1000
+
+//pluralCategories 通过代入样本数量，推断每个复数形式下标对应的CLDR复数类别
+func (c *Catalog) pluralCategories() []plural.Category {
+	categories := make([]plural.Category, c.nplurals)
+	rule := plural.CardinalRule(c.Language())
+	for n := 0; n <= maxPluralSample; n++ {
+		index := c.PluralIndex(n)
+		if categories[index] == "" {
+			categories[index] = rule(plural.Operands{N: float64(n), I: int64(n)})
+		}
+	}
+	for i, category := range categories {
+		if category == "" {
+			categories[i] = plural.Other
+		}
+	}
+	return categories
+}
+
+//ToCatalog 转换为通用的翻译目录，默认忽略fuzzy条目（UseFuzzy为true时保留）
+func ToCatalog(c *Catalog) *catalog.Catalog {
+	result := catalog.New(c.Language())
+	categories := c.pluralCategories()
+	for _, m := range c.Messages() {
+		if m.Fuzzy && !c.UseFuzzy {
+			continue
+		}
+		msg := &catalog.Message{
+			Key:       m.ID,
+			Context:   m.Context,
+			Comment:   strings.Join(append(append([]string{}, m.ExtractedComments...), m.Comments...), "\n"),
+			Locations: m.References,
+		}
+		if len(m.Str) > 0 {
+			msg.Text = m.Str[0]
+		}
+		if m.IDPlural != "" {
+			msg.Plurals = make(map[plural.Category]string)
+			for i, str := range m.Str {
+				if i < len(categories) {
+					msg.Plurals[categories[i]] = str
+				}
+			}
+			if other, ok := msg.Plurals[plural.Other]; ok {
+				msg.Text = other
+			}
+		}
+		result.Add(msg)
+	}
+	return result
+}
+
+//FromCatalog 从通用的翻译目录创建gettext翻译目录，pluralForms为空时使用英语的复数规则
+func FromCatalog(c *catalog.Catalog, pluralForms string) (*Catalog, error) {
+	result := NewCatalog()
+	header := "Language: " + c.Locale + "\nMIME-Version: 1.0\nContent-Type: text/plain; charset=UTF-8\nContent-Transfer-Encoding: 8bit\n"
+	if pluralForms == "" {
+		pluralForms = "nplurals=2; plural=(n != 1);"
+	}
+	header += "Plural-Forms: " + pluralForms + "\n"
+	if err := result.SetHeader(header); err != nil {
+		return nil, err
+	}
+	categories := result.pluralCategories()
+	for _, m := range c.Messages() {
+		msg := &Message{
+			Context:    m.Context,
+			ID:         m.Key,
+			Str:        []string{m.Text},
+			References: m.Locations,
+		}
+		if m.Comment != "" {
+			msg.ExtractedComments = strings.Split(m.Comment, "\n")
+		}
+		if len(m.Plurals) > 0 {
+			msg.IDPlural = m.Key
+			msg.Str = make([]string, len(categories))
+			for i, category := range categories {
+				text, ok := m.Plurals[category]
+				if !ok {
+					text = m.Text
+				}
+				msg.Str[i] = text
+			}
+		}
+		if err := result.Add(msg); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
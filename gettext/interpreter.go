@@ -0,0 +1,134 @@
+package gettext
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Khellendros97/khutils/format"
+)
+
+//LoadFile 根据扩展名加载.po、.pot或.mo文件
+func LoadFile(path string) (*Catalog, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".po", ".pot":
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return ParsePO(file)
+	case ".mo":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return ParseMO(data)
+	default:
+		return nil, fmt.Errorf("unknown catalog file: %s", path)
+	}
+}
+
+//Interpreter 基于gettext翻译目录的表达式解释器
+//{{zh_CN::hello}}按msgid查找翻译；{{zh_CN::apples($0)}}中第一个参数同时作为复数形式的数量
+//译文中包含%动词时，会像fmt.Sprintf一样使用实参格式化。
+//没有翻译的msgid返回ErrKeyNotFound，EchoID为true时像gettext一样返回msgid本身
+type Interpreter struct {
+	Catalog *Catalog
+	Context string // 查找时使用的msgctxt
+	EchoID  bool   // 没有翻译时返回msgid
+}
+
+func NewInterpreter(catalog *Catalog) *Interpreter {
+	return &Interpreter{Catalog: catalog}
+}
+
+//WithContext 返回在指定msgctxt下查找的解释器，可以注册到另一个命名空间
+func (i *Interpreter) WithContext(context string) *Interpreter {
+	return &Interpreter{Catalog: i.Catalog, Context: context, EchoID: i.EchoID}
+}
+
+//Locale 返回翻译目录头部声明的语言
+func (i *Interpreter) Locale() string {
+	return i.Catalog.Language()
+}
+
+func (i *Interpreter) Format(key string, args []any) (string, error) {
+	str := key
+	m := i.Catalog.Lookup(i.Context, key)
+	if m == nil && !i.EchoID {
+		return "", fmt.Errorf("%w: %s", format.ErrKeyNotFound, key)
+	}
+	if m != nil {
+		str = m.Str[0]
+		if m.IDPlural != "" && len(args) > 0 {
+			n, err := toCount(args[0])
+			if err != nil {
+				return "", err
+			}
+			str = i.Catalog.pluralStr(m, n)
+		}
+	}
+	if len(args) > 0 && strings.Contains(str, "%") {
+		str = fmt.Sprintf(str, args...)
+	}
+	return str, nil
+}
+
+//toCount 将复数形式的数量参数转换为整数
+func toCount(value any) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int8:
+		return int(v), nil
+	case int16:
+		return int(v), nil
+	case int32:
+		return int(v), nil
+	case int64:
+		return int(v), nil
+	case uint:
+		return int(v), nil
+	case uint8:
+		return int(v), nil
+	case uint16:
+		return int(v), nil
+	case uint32:
+		return int(v), nil
+	case uint64:
+		return int(v), nil
+	case float32:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("invalid plural count: %s", v)
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("invalid plural count: %v", value)
+}
+
+//Register 将翻译目录作为表达式解释器注册到locale命名空间
+//例如Register("zh_CN", catalog)之后可以使用{{zh_CN::hello}}，locale为tpl时返回错误
+func Register(locale string, catalog *Catalog) (*Interpreter, error) {
+	interpreter := NewInterpreter(catalog)
+	if err := format.RegisterInterpreter(locale, interpreter); err != nil {
+		return nil, err
+	}
+	return interpreter, nil
+}
+
+//RegisterFile 加载翻译目录文件并注册到locale命名空间
+func RegisterFile(locale string, path string) (*Interpreter, error) {
+	catalog, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Register(locale, catalog)
+}
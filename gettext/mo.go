@@ -0,0 +1,69 @@
+package gettext
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+const (
+	moMagicLE = 0x950412de
+	moMagicBE = 0xde120495
+)
+
+//ParseMO 解析msgfmt生成的二进制.mo文件
+func ParseMO(data []byte) (*Catalog, error) {
+	if len(data) < 28 {
+		return nil, fmt.Errorf("mo file too short")
+	}
+	var order binary.ByteOrder
+	switch binary.LittleEndian.Uint32(data) {
+	case moMagicLE:
+		order = binary.LittleEndian
+	case moMagicBE:
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("invalid mo magic number")
+	}
+	if revision := order.Uint32(data[4:]) >> 16; revision > 1 {
+		return nil, fmt.Errorf("unsupported mo revision: %d", revision)
+	}
+	count := int(order.Uint32(data[8:]))
+	origTable := int(order.Uint32(data[12:]))
+	transTable := int(order.Uint32(data[16:]))
+
+	readString := func(table, i int) (string, error) {
+		entry := table + i*8
+		if entry < 0 || entry+8 > len(data) {
+			return "", fmt.Errorf("mo string table out of range")
+		}
+		length := int(order.Uint32(data[entry:]))
+		offset := int(order.Uint32(data[entry+4:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return "", fmt.Errorf("mo string out of range")
+		}
+		return string(data[offset : offset+length]), nil
+	}
+
+	catalog := NewCatalog()
+	for i := 0; i < count; i++ {
+		orig, err := readString(origTable, i)
+		if err != nil {
+			return nil, err
+		}
+		trans, err := readString(transTable, i)
+		if err != nil {
+			return nil, err
+		}
+		msg := &Message{}
+		if context, id, ok := strings.Cut(orig, contextSeparator); ok {
+			msg.Context, orig = context, id
+		}
+		msg.ID, msg.IDPlural, _ = strings.Cut(orig, "\x00")
+		msg.Str = strings.Split(trans, "\x00")
+		if err := catalog.Add(msg); err != nil {
+			return nil, err
+		}
+	}
+	return catalog, nil
+}
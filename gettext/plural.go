@@ -0,0 +1,271 @@
+package gettext
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//PluralFunc 根据数量n返回应使用的复数形式下标
+type PluralFunc func(n int) int
+
+//defaultPlural 未声明Plural-Forms时使用的规则（与英语相同）
+func defaultPlural(n int) int {
+	if n == 1 {
+		return 0
+	}
+	return 1
+}
+
+//ParsePluralForms 解析Plural-Forms头，例如"nplurals=2; plural=(n != 1);"
+//@return nplurals 复数形式的数量; fn 复数形式选择函数
+func ParsePluralForms(header string) (nplurals int, fn PluralFunc, err error) {
+	nplurals = 2
+	fn = defaultPlural
+	var expr string
+	for _, field := range strings.Split(header, ";") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return 0, nil, fmt.Errorf("invalid plural forms: %s", header)
+		}
+		switch strings.TrimSpace(kv[0]) {
+		case "nplurals":
+			nplurals, err = strconv.Atoi(strings.TrimSpace(kv[1]))
+			if err != nil || nplurals <= 0 {
+				return 0, nil, fmt.Errorf("invalid nplurals: %s", kv[1])
+			}
+		case "plural":
+			expr = kv[1]
+		}
+	}
+	if expr == "" {
+		return
+	}
+	p := &pluralParser{expr: expr}
+	node, err := p.parseTernary()
+	if err != nil {
+		return 0, nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.expr) {
+		return 0, nil, fmt.Errorf("unexpected '%s' in plural expression", p.expr[p.pos:])
+	}
+	fn = func(n int) int {
+		return node.eval(n)
+	}
+	return
+}
+
+//pluralNode Plural-Forms中C语言子集表达式的语法树节点
+type pluralNode interface {
+	eval(n int) int
+}
+
+type pluralVar struct{}
+
+func (pluralVar) eval(n int) int {
+	return n
+}
+
+type pluralConst int
+
+func (c pluralConst) eval(int) int {
+	return int(c)
+}
+
+type pluralNot struct {
+	x pluralNode
+}
+
+func (e *pluralNot) eval(n int) int {
+	return boolToInt(e.x.eval(n) == 0)
+}
+
+type pluralBinary struct {
+	op          string
+	left, right pluralNode
+}
+
+func (e *pluralBinary) eval(n int) int {
+	// 逻辑运算需要短路求值
+	switch e.op {
+	case "&&":
+		return boolToInt(e.left.eval(n) != 0 && e.right.eval(n) != 0)
+	case "||":
+		return boolToInt(e.left.eval(n) != 0 || e.right.eval(n) != 0)
+	}
+	l, r := e.left.eval(n), e.right.eval(n)
+	switch e.op {
+	case "==":
+		return boolToInt(l == r)
+	case "!=":
+		return boolToInt(l != r)
+	case "<":
+		return boolToInt(l < r)
+	case "<=":
+		return boolToInt(l <= r)
+	case ">":
+		return boolToInt(l > r)
+	case ">=":
+		return boolToInt(l >= r)
+	case "+":
+		return l + r
+	case "-":
+		return l - r
+	case "*":
+		return l * r
+	case "/":
+		if r == 0 {
+			return 0
+		}
+		return l / r
+	case "%":
+		if r == 0 {
+			return 0
+		}
+		return l % r
+	}
+	return 0
+}
+
+type pluralTernary struct {
+	cond, yes, no pluralNode
+}
+
+func (e *pluralTernary) eval(n int) int {
+	if e.cond.eval(n) != 0 {
+		return e.yes.eval(n)
+	}
+	return e.no.eval(n)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+//pluralLevels 二元运算符按优先级从低到高排列
+var pluralLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<=", ">=", "<", ">"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+type pluralParser struct {
+	expr string
+	pos  int
+}
+
+func (p *pluralParser) skipSpace() {
+	for p.pos < len(p.expr) && strings.ContainsRune(" \t\r\n", rune(p.expr[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *pluralParser) accept(tok string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.expr[p.pos:], tok) {
+		p.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+func (p *pluralParser) parseTernary() (pluralNode, error) {
+	cond, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept("?") {
+		return cond, nil
+	}
+	yes, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept(":") {
+		return nil, fmt.Errorf("missing ':' in plural expression")
+	}
+	no, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	return &pluralTernary{cond: cond, yes: yes, no: no}, nil
+}
+
+func (p *pluralParser) parseBinary(level int) (pluralNode, error) {
+	if level >= len(pluralLevels) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := ""
+		for _, candidate := range pluralLevels[level] {
+			if p.accept(candidate) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			return left, nil
+		}
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &pluralBinary{op: op, left: left, right: right}
+	}
+}
+
+func (p *pluralParser) parseUnary() (pluralNode, error) {
+	p.skipSpace()
+	if p.pos >= len(p.expr) {
+		return nil, fmt.Errorf("unexpected end of plural expression")
+	}
+	ch := p.expr[p.pos]
+	switch {
+	case ch == '!' && !strings.HasPrefix(p.expr[p.pos:], "!="):
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &pluralNot{x: x}, nil
+	case ch == '(':
+		p.pos++
+		x, err := p.parseTernary()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing ')' in plural expression")
+		}
+		return x, nil
+	case ch == 'n':
+		p.pos++
+		return pluralVar{}, nil
+	case ch >= '0' && ch <= '9':
+		start := p.pos
+		for p.pos < len(p.expr) && p.expr[p.pos] >= '0' && p.expr[p.pos] <= '9' {
+			p.pos++
+		}
+		v, err := strconv.Atoi(p.expr[start:p.pos])
+		if err != nil {
+			return nil, err
+		}
+		return pluralConst(v), nil
+	}
+	return nil, fmt.Errorf("unexpected '%c' in plural expression", ch)
+}
@@ -0,0 +1,296 @@
+package gettext
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+//poParser 逐行解析.po/.pot文件
+type poParser struct {
+	catalog *Catalog
+	line    int
+	msg     *Message
+	field   *string // 当前正在追加续行的字段
+	hasStr  bool    // 当前条目是否已经出现过msgstr
+	hasID   bool    // 当前条目是否已经出现过msgid
+}
+
+//ParsePO 解析.po或.pot格式的翻译目录
+func ParsePO(r io.Reader) (*Catalog, error) {
+	p := &poParser{catalog: NewCatalog()}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		p.line++
+		if err := p.parseLine(strings.TrimSpace(scanner.Text())); err != nil {
+			return nil, fmt.Errorf("po line %d: %w", p.line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := p.flush(); err != nil {
+		return nil, err
+	}
+	return p.catalog, nil
+}
+
+func (p *poParser) flush() error {
+	msg, hasID := p.msg, p.hasID
+	p.msg, p.field, p.hasStr, p.hasID = nil, nil, false, false
+	if msg == nil || !hasID {
+		return nil
+	}
+	return p.catalog.Add(msg)
+}
+
+//current 返回正在解析的条目，上一个条目已经结束时开始新的条目
+func (p *poParser) current(keyword string) (*Message, error) {
+	startsEntry := keyword == "msgctxt" || keyword == "msgid"
+	if p.msg != nil && p.hasStr && startsEntry {
+		if err := p.flush(); err != nil {
+			return nil, err
+		}
+	}
+	if p.msg == nil {
+		p.msg = &Message{}
+	}
+	return p.msg, nil
+}
+
+func (p *poParser) parseLine(line string) error {
+	if line == "" {
+		return p.flush()
+	}
+	if line[0] == '#' {
+		return p.parseComment(line)
+	}
+	if line[0] == '"' {
+		if p.field == nil {
+			return fmt.Errorf("unexpected string continuation")
+		}
+		s, err := unquotePO(line)
+		if err != nil {
+			return err
+		}
+		*p.field += s
+		return nil
+	}
+	keyword, rest, _ := strings.Cut(line, " ")
+	value, err := unquotePO(strings.TrimSpace(rest))
+	if err != nil {
+		return err
+	}
+	msg, err := p.current(keyword)
+	if err != nil {
+		return err
+	}
+	switch {
+	case keyword == "msgctxt":
+		msg.Context = value
+		p.field = &msg.Context
+	case keyword == "msgid":
+		msg.ID = value
+		p.field = &msg.ID
+		p.hasID = true
+	case keyword == "msgid_plural":
+		msg.IDPlural = value
+		p.field = &msg.IDPlural
+	case keyword == "msgstr":
+		msg.Str = []string{value}
+		p.field = &msg.Str[0]
+		p.hasStr = true
+	case strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]"):
+		index, err := strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1])
+		if err != nil || index < 0 {
+			return fmt.Errorf("invalid keyword: %s", keyword)
+		}
+		for len(msg.Str) <= index {
+			msg.Str = append(msg.Str, "")
+		}
+		msg.Str[index] = value
+		p.field = &msg.Str[index]
+		p.hasStr = true
+	default:
+		return fmt.Errorf("unknown keyword: %s", keyword)
+	}
+	return nil
+}
+
+func (p *poParser) parseComment(line string) error {
+	if strings.HasPrefix(line, "#~") { // 废弃条目直接忽略
+		return nil
+	}
+	if p.hasStr {
+		// 注释出现在msgstr之后，说明开始了新的条目
+		if err := p.flush(); err != nil {
+			return err
+		}
+	}
+	msg, err := p.current("#")
+	if err != nil {
+		return err
+	}
+	p.field = nil
+	switch {
+	case strings.HasPrefix(line, "#,"):
+		for _, flag := range strings.Split(line[2:], ",") {
+			flag = strings.TrimSpace(flag)
+			if flag == "" {
+				continue
+			}
+			if flag == "fuzzy" {
+				msg.Fuzzy = true
+			} else {
+				msg.Flags = append(msg.Flags, flag)
+			}
+		}
+	case strings.HasPrefix(line, "#."):
+		msg.ExtractedComments = append(msg.ExtractedComments, strings.TrimSpace(line[2:]))
+	case strings.HasPrefix(line, "#:"):
+		msg.References = append(msg.References, strings.Fields(line[2:])...)
+	case strings.HasPrefix(line, "#|"):
+		// 上一版本的msgid，仅供翻译人员参考
+	default:
+		msg.Comments = append(msg.Comments, strings.TrimSpace(line[1:]))
+	}
+	return nil
+}
+
+//unquotePO 解析.po中带双引号的C风格字符串
+func unquotePO(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("invalid string: %s", s)
+	}
+	s = s[1 : len(s)-1]
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch != '\\' {
+			sb.WriteByte(ch)
+			continue
+		}
+		i++
+		if i >= len(s) {
+			return "", fmt.Errorf("invalid escape at end of string")
+		}
+		switch s[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'a':
+			sb.WriteByte('\a')
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'v':
+			sb.WriteByte('\v')
+		case '\\', '"', '\'', '?':
+			sb.WriteByte(s[i])
+		case 'x':
+			j := i + 1
+			for j < len(s) && j < i+3 && isHexDigit(s[j]) {
+				j++
+			}
+			v, err := strconv.ParseUint(s[i+1:j], 16, 8)
+			if err != nil {
+				return "", fmt.Errorf("invalid hex escape: %s", s[i-1:j])
+			}
+			sb.WriteByte(byte(v))
+			i = j - 1
+		default:
+			if s[i] < '0' || s[i] > '7' {
+				return "", fmt.Errorf("invalid escape: \\%c", s[i])
+			}
+			j := i
+			for j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7' {
+				j++
+			}
+			v, err := strconv.ParseUint(s[i:j], 8, 8)
+			if err != nil {
+				return "", fmt.Errorf("invalid octal escape: %s", s[i-1:j])
+			}
+			sb.WriteByte(byte(v))
+			i = j - 1
+		}
+	}
+	return sb.String(), nil
+}
+
+func isHexDigit(ch byte) bool {
+	return (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
+
+//quotePO 将字符串转换为.po中带双引号的形式，包含换行时拆分为多行
+func quotePO(s string) string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\t", `\t`, "\r", `\r`, "\n", `\n`)
+	lines := strings.SplitAfter(s, "\n")
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= 1 {
+		return `"` + escape.Replace(s) + `"`
+	}
+	var sb strings.Builder
+	sb.WriteString(`""`)
+	for _, line := range lines {
+		sb.WriteString("\n\"" + escape.Replace(line) + `"`)
+	}
+	return sb.String()
+}
+
+//WritePO 将翻译目录写出为.po格式，写出.pot模板时条目的msgstr留空即可
+func WritePO(w io.Writer, c *Catalog) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "msgid \"\"\nmsgstr %s\n", quotePO(c.HeaderString()))
+	for _, m := range c.Messages() {
+		bw.WriteString("\n")
+		for _, comment := range m.Comments {
+			fmt.Fprintf(bw, "# %s\n", comment)
+		}
+		for _, comment := range m.ExtractedComments {
+			fmt.Fprintf(bw, "#. %s\n", comment)
+		}
+		for _, ref := range m.References {
+			fmt.Fprintf(bw, "#: %s\n", ref)
+		}
+		flags := m.Flags
+		if m.Fuzzy {
+			flags = append([]string{"fuzzy"}, flags...)
+		}
+		if len(flags) > 0 {
+			fmt.Fprintf(bw, "#, %s\n", strings.Join(flags, ", "))
+		}
+		if m.Context != "" {
+			fmt.Fprintf(bw, "msgctxt %s\n", quotePO(m.Context))
+		}
+		fmt.Fprintf(bw, "msgid %s\n", quotePO(m.ID))
+		if m.IDPlural == "" {
+			str := ""
+			if len(m.Str) > 0 {
+				str = m.Str[0]
+			}
+			fmt.Fprintf(bw, "msgstr %s\n", quotePO(str))
+			continue
+		}
+		fmt.Fprintf(bw, "msgid_plural %s\n", quotePO(m.IDPlural))
+		strs := m.Str
+		if len(strs) == 0 {
+			strs = make([]string, c.NPlurals())
+		}
+		for i, str := range strs {
+			fmt.Fprintf(bw, "msgstr[%d] %s\n", i, quotePO(str))
+		}
+	}
+	return bw.Flush()
+}
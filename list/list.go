@@ -0,0 +1,193 @@
+package list
+
+import (
+	"strings"
+
+	"github.com/Khellendros97/khutils/plural"
+)
+
+//Type CLDR列表类型
+type Type string
+
+const (
+	Conjunction Type = "conjunction" // a, b, and c
+	Disjunction Type = "disjunction" // a, b, or c
+	Unit        Type = "unit"        // 3 feet, 7 inches
+)
+
+//Width CLDR列表宽度
+type Width string
+
+const (
+	Standard Width = "standard"
+	Short    Width = "short"
+	Narrow   Width = "narrow"
+)
+
+//Pattern CLDR列表模式，{0}和{1}分别为前面的部分和后面的部分
+//三个及以上的元素：Start连接前两个元素，Middle连接中间的元素，End连接最后两个元素；两个元素使用Two
+type Pattern struct {
+	Start  string
+	Middle string
+	End    string
+	Two    string
+}
+
+//ParseType 解析列表类型，支持CLDR的名字以及and、or
+func ParseType(s string) (Type, bool) {
+	switch strings.ToLower(s) {
+	case "conjunction", "and":
+		return Conjunction, true
+	case "disjunction", "or":
+		return Disjunction, true
+	case "unit":
+		return Unit, true
+	}
+	return "", false
+}
+
+//ParseWidth 解析列表宽度
+func ParseWidth(s string) (Width, bool) {
+	switch strings.ToLower(s) {
+	case string(Standard), "wide", "long":
+		return Standard, true
+	case string(Short), string(Narrow):
+		return Width(strings.ToLower(s)), true
+	}
+	return "", false
+}
+
+//key 模式表中的键，例如conjunction-short
+func key(t Type, w Width) string {
+	return string(t) + "-" + string(w)
+}
+
+//Lookup 返回语言的列表模式。缺少的宽度按narrow => short => standard回退，未知语言使用英语
+func Lookup(locale string, t Type, w Width) Pattern {
+	patterns, ok := patternTable[plural.Language(locale)]
+	if !ok {
+		patterns = patternTable["en"]
+	}
+	if t == "" {
+		t = Conjunction
+	}
+	widths := []Width{Standard}
+	switch w {
+	case Short:
+		widths = []Width{Short, Standard}
+	case Narrow:
+		widths = []Width{Narrow, Short, Standard}
+	}
+	for _, width := range widths {
+		if p, ok := patterns[key(t, width)]; ok {
+			return p
+		}
+	}
+	return patternTable["en"][key(t, Standard)]
+}
+
+//SetPattern 注册或覆盖一个语言的列表模式
+func SetPattern(language string, t Type, w Width, p Pattern) {
+	language = plural.Language(language)
+	if patternTable[language] == nil {
+		patternTable[language] = make(map[string]Pattern)
+	}
+	patternTable[language][key(t, w)] = p
+}
+
+func apply(pattern string, first string, second string) string {
+	return strings.NewReplacer("{0}", first, "{1}", second).Replace(pattern)
+}
+
+//Join 按模式连接元素
+func (p Pattern) Join(items []string) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	case 2:
+		return apply(p.Two, items[0], items[1])
+	}
+	n := len(items)
+	result := apply(p.End, items[n-2], items[n-1])
+	for i := n - 3; i > 0; i-- {
+		result = apply(p.Middle, items[i], result)
+	}
+	return apply(p.Start, items[0], result)
+}
+
+//Format 按语言的列表模式连接元素，例如Format("en", []string{"a", "b", "c"}, Conjunction, Standard) => "a, b, and c"
+func Format(locale string, items []string, t Type, w Width) string {
+	return Lookup(locale, t, w).Join(items)
+}
+
+//series 开头和中间使用相同连接符的模式
+func series(sep string, end string, two string) Pattern {
+	return Pattern{Start: "{0}" + sep + "{1}", Middle: "{0}" + sep + "{1}", End: "{0}" + end + "{1}", Two: "{0}" + two + "{1}"}
+}
+
+//patternTable 内置语言的列表模式，来自CLDR
+var patternTable = map[string]map[string]Pattern{
+	"en": {
+		"conjunction-standard": series(", ", ", and ", " and "),
+		"conjunction-short":    series(", ", ", & ", " & "),
+		"conjunction-narrow":   series(", ", ", ", ", "),
+		"disjunction-standard": series(", ", ", or ", " or "),
+		"unit-standard":        series(", ", ", ", ", "),
+		"unit-narrow":          series(" ", " ", " "),
+	},
+	"zh": {
+		"conjunction-standard": series("、", "和", "和"),
+		"disjunction-standard": series("、", "或", "或"),
+		"unit-standard":        series("", "", ""),
+	},
+	"ja": {
+		"conjunction-standard": series("、", "、", "、"),
+		"disjunction-standard": series("、", "、または", "または"),
+		"unit-standard":        series(" ", " ", " "),
+		"unit-narrow":          series("", "", ""),
+	},
+	"ko": {
+		"conjunction-standard": series(", ", " 및 ", " 및 "),
+		"disjunction-standard": series(", ", " 또는 ", " 또는 "),
+		"unit-standard":        series(" ", " ", " "),
+	},
+	"fr": {
+		"conjunction-standard": series(", ", " et ", " et "),
+		"conjunction-narrow":   series(", ", ", ", ", "),
+		"disjunction-standard": series(", ", " ou ", " ou "),
+		"unit-standard":        series(", ", " et ", " et "),
+		"unit-narrow":          series(" ", " ", " "),
+	},
+	"de": {
+		"conjunction-standard": series(", ", " und ", " und "),
+		"disjunction-standard": series(", ", " oder ", " oder "),
+		"unit-standard":        series(", ", " und ", " und "),
+		"unit-narrow":          series(", ", " und ", " und "),
+	},
+	"es": {
+		"conjunction-standard": series(", ", " y ", " y "),
+		"disjunction-standard": series(", ", " o ", " o "),
+		"unit-standard":        series(", ", " y ", " y "),
+		"unit-narrow":          series(" ", " ", " "),
+	},
+	"it": {
+		"conjunction-standard": series(", ", " e ", " e "),
+		"disjunction-standard": series(", ", " o ", " o "),
+		"unit-standard":        series(", ", " e ", " e "),
+		"unit-narrow":          series(" ", " ", " "),
+	},
+	"pt": {
+		"conjunction-standard": series(", ", " e ", " e "),
+		"disjunction-standard": series(", ", " ou ", " ou "),
+		"unit-standard":        series(", ", " e ", " e "),
+		"unit-narrow":          series(" ", " ", " "),
+	},
+	"ru": {
+		"conjunction-standard": series(", ", " и ", " и "),
+		"disjunction-standard": series(", ", " или ", " или "),
+		"unit-standard":        series(", ", " и ", " и "),
+		"unit-narrow":          series(" ", " ", " "),
+	},
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	//"strings"
@@ -63,6 +64,9 @@ func SetConcat() {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
 	Lang = "US"
 	SetConcat()
 	format.RegisterInterpreter("Lang", &LangInterpreter{})
@@ -0,0 +1,202 @@
+package mobile
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Khellendros97/khutils/catalog"
+	"github.com/Khellendros97/khutils/plural"
+)
+
+//unescapeAndroid 还原Android字符串资源的转义：去掉包裹的双引号（否则合并空白），处理\n、\'、\uXXXX等
+func unescapeAndroid(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	} else {
+		s = strings.Join(strings.Fields(s), " ")
+	}
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'u':
+			if i+4 < len(s) {
+				if code, err := strconv.ParseUint(s[i+1:i+5], 16, 32); err == nil {
+					sb.WriteRune(rune(code))
+					i += 4
+					continue
+				}
+			}
+			sb.WriteString("\\u")
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
+
+//escapeAndroid 转义Android字符串资源中的特殊字符
+func escapeAndroid(s string) string {
+	var sb strings.Builder
+	for i, ch := range s {
+		switch ch {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\'':
+			sb.WriteString(`\'`)
+		case '"':
+			sb.WriteString(`\"`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '@', '?':
+			if i == 0 {
+				sb.WriteByte('\\')
+			}
+			sb.WriteRune(ch)
+		default:
+			sb.WriteRune(ch)
+		}
+	}
+	escaped := escapeXML(sb.String())
+	// 首尾空白和连续空白需要用双引号包裹才能保留
+	if strings.TrimSpace(s) != s || strings.Contains(s, "  ") {
+		escaped = `"` + escaped + `"`
+	}
+	return escaped
+}
+
+//escapeXML 只转义XML中必须转义的字符，保持引号的可读性
+func escapeXML(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+//readAndroidText 读取元素的内容直到结束标签，内联的HTML标记原样保留，xliff:g只保留其中的文本
+func readAndroidText(decoder *xml.Decoder) (string, error) {
+	var sb strings.Builder
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			sb.Write(t)
+		case xml.StartElement:
+			depth++
+			if t.Name.Local != "g" {
+				sb.WriteString("<" + t.Name.Local + ">")
+			}
+		case xml.EndElement:
+			if depth == 0 {
+				return unescapeAndroid(sb.String()), nil
+			}
+			depth--
+			if t.Name.Local != "g" {
+				sb.WriteString("</" + t.Name.Local + ">")
+			}
+		}
+	}
+}
+
+func attrValue(start xml.StartElement, name string) string {
+	for _, a := range start.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+//ReadAndroid 读取Android的strings.xml，支持<string>和<plurals>，紧邻元素之前的注释作为消息的注释
+func ReadAndroid(r io.Reader, locale string) (*catalog.Catalog, error) {
+	c := catalog.New(locale)
+	decoder := xml.NewDecoder(r)
+	comment := ""
+	var current *catalog.Message
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return c, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("android: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.Comment:
+			comment = strings.TrimSpace(string(t))
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "string":
+				text, err := readAndroidText(decoder)
+				if err != nil {
+					return nil, fmt.Errorf("android: %w", err)
+				}
+				m := c.Set(attrValue(t, "name"), FromPrintf(text))
+				m.Comment, comment = comment, ""
+			case "plurals":
+				current = &catalog.Message{Key: attrValue(t, "name"), Comment: comment, Plurals: make(map[plural.Category]string)}
+				comment = ""
+			case "item":
+				if current == nil {
+					continue
+				}
+				text, err := readAndroidText(decoder)
+				if err != nil {
+					return nil, fmt.Errorf("android: %w", err)
+				}
+				category := plural.Category(attrValue(t, "quantity"))
+				current.Plurals[category] = FromPrintf(text)
+				if category == plural.Other {
+					current.Text = current.Plurals[category]
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "plurals" && current != nil {
+				c.Add(current)
+				current = nil
+			}
+		}
+	}
+}
+
+//WriteAndroid 写出Android的strings.xml，{0}写作%1$s，复数形式中的{0}作为数量写作%1$d
+func WriteAndroid(w io.Writer, c *catalog.Catalog) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(xml.Header)
+	bw.WriteString("<resources>\n")
+	for _, m := range c.Messages() {
+		if m.Comment != "" {
+			fmt.Fprintf(bw, "    <!-- %s -->\n", strings.ReplaceAll(m.Comment, "--", "- -"))
+		}
+		if len(m.Plurals) == 0 {
+			fmt.Fprintf(bw, "    <string name=\"%s\">%s</string>\n", escapeXML(m.Key), escapeAndroid(ToPrintf(m.Text, "s")))
+			continue
+		}
+		fmt.Fprintf(bw, "    <plurals name=\"%s\">\n", escapeXML(m.Key))
+		for _, category := range m.PluralCategories() {
+			text := ToPrintf(countPlaceholder(m.Plurals[category]), "s")
+			fmt.Fprintf(bw, "        <item quantity=\"%s\">%s</item>\n", category, escapeAndroid(text))
+		}
+		bw.WriteString("    </plurals>\n")
+	}
+	bw.WriteString("</resources>\n")
+	return bw.Flush()
+}
@@ -0,0 +1,297 @@
+package mobile
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Khellendros97/khutils/catalog"
+	"github.com/Khellendros97/khutils/plural"
+)
+
+//stringsParser 解析Apple的.strings文件："key" = "value"; 以及/* */和//注释
+type stringsParser struct {
+	src     string
+	pos     int
+	comment string
+}
+
+func (p *stringsParser) errorf(format string, args ...any) error {
+	line := strings.Count(p.src[:p.pos], "\n") + 1
+	return fmt.Errorf("strings line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+//skip 跳过空白和注释，记录最近的注释
+func (p *stringsParser) skip() error {
+	for p.pos < len(p.src) {
+		switch {
+		case strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0:
+			p.pos++
+		case strings.HasPrefix(p.src[p.pos:], "\ufeff"): // BOM
+			p.pos += len("\ufeff")
+		case strings.HasPrefix(p.src[p.pos:], "/*"):
+			end := strings.Index(p.src[p.pos+2:], "*/")
+			if end < 0 {
+				return p.errorf("unterminated comment")
+			}
+			p.comment = strings.TrimSpace(p.src[p.pos+2 : p.pos+2+end])
+			p.pos += end + 4
+		case strings.HasPrefix(p.src[p.pos:], "//"):
+			end := strings.IndexByte(p.src[p.pos:], '\n')
+			if end < 0 {
+				end = len(p.src) - p.pos
+			}
+			p.comment = strings.TrimSpace(p.src[p.pos+2 : p.pos+end])
+			p.pos += end
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (p *stringsParser) parseString() (string, error) {
+	if p.pos >= len(p.src) || p.src[p.pos] != '"' {
+		return "", p.errorf("expected '\"'")
+	}
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		ch := p.src[p.pos]
+		p.pos++
+		switch ch {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if p.pos >= len(p.src) {
+				return "", p.errorf("unterminated string")
+			}
+			esc := p.src[p.pos]
+			p.pos++
+			switch esc {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case 'U', 'u':
+				if p.pos+4 > len(p.src) {
+					return "", p.errorf("invalid unicode escape")
+				}
+				code, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 32)
+				if err != nil {
+					return "", p.errorf("invalid unicode escape")
+				}
+				sb.WriteRune(rune(code))
+				p.pos += 4
+			default:
+				sb.WriteByte(esc)
+			}
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *stringsParser) expect(ch byte) error {
+	if err := p.skip(); err != nil {
+		return err
+	}
+	if p.pos >= len(p.src) || p.src[p.pos] != ch {
+		return p.errorf("expected '%c'", ch)
+	}
+	p.pos++
+	return nil
+}
+
+//ReadStrings 读取Apple的.strings文件（UTF-8编码），紧邻条目之前的注释作为消息的注释
+func ReadStrings(r io.Reader, locale string) (*catalog.Catalog, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &stringsParser{src: string(data)}
+	c := catalog.New(locale)
+	for {
+		p.comment = ""
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.src) {
+			return c, nil
+		}
+		comment := p.comment
+		key, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect('='); err != nil {
+			return nil, err
+		}
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		value, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(';'); err != nil {
+			return nil, err
+		}
+		m := c.Set(key, FromPrintf(value))
+		m.Comment = comment
+	}
+}
+
+func quoteStrings(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
+
+//WriteStrings 写出Apple的.strings文件，{0}写作%1$@。含复数形式的消息应该写入.stringsdict，这里会跳过
+func WriteStrings(w io.Writer, c *catalog.Catalog) error {
+	bw := bufio.NewWriter(w)
+	first := true
+	for _, m := range c.Messages() {
+		if len(m.Plurals) > 0 {
+			continue
+		}
+		if !first {
+			bw.WriteString("\n")
+		}
+		first = false
+		if m.Comment != "" {
+			fmt.Fprintf(bw, "/* %s */\n", strings.ReplaceAll(m.Comment, "*/", "* /"))
+		}
+		fmt.Fprintf(bw, "%s = %s;\n", quoteStrings(m.Key), quoteStrings(ToPrintf(m.Text, "@")))
+	}
+	return bw.Flush()
+}
+
+//plistNode plist中的值：dict、string等
+type plistNode struct {
+	kind  string
+	text  string
+	keys  []string
+	items map[string]*plistNode
+}
+
+func readPlistValue(decoder *xml.Decoder, start xml.StartElement) (*plistNode, error) {
+	n := &plistNode{kind: start.Name.Local}
+	if n.kind != "dict" {
+		var text string
+		if err := decoder.DecodeElement(&text, &start); err != nil {
+			return nil, err
+		}
+		n.text = text
+		return n, nil
+	}
+	n.items = make(map[string]*plistNode)
+	key := ""
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "key" {
+				if err := decoder.DecodeElement(&key, &t); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			value, err := readPlistValue(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			n.keys = append(n.keys, key)
+			n.items[key] = value
+		case xml.EndElement:
+			return n, nil
+		}
+	}
+}
+
+//pluralVariable .stringsdict中引用复数变量的格式，例如%#@count@
+var pluralVariable = regexp.MustCompile(`%(?:\d+\$)?#@([^@]+)@`)
+
+//ReadStringsDict 读取Apple的.stringsdict文件，每个条目只支持一个复数变量
+func ReadStringsDict(r io.Reader, locale string) (*catalog.Catalog, error) {
+	decoder := xml.NewDecoder(r)
+	var root *plistNode
+	for root == nil {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("stringsdict: %w", err)
+		}
+		if t, ok := token.(xml.StartElement); ok && t.Name.Local == "dict" {
+			if root, err = readPlistValue(decoder, t); err != nil {
+				return nil, fmt.Errorf("stringsdict: %w", err)
+			}
+		}
+	}
+	c := catalog.New(locale)
+	for _, key := range root.keys {
+		entry := root.items[key]
+		if entry.kind != "dict" {
+			continue
+		}
+		formatKey := entry.items["NSStringLocalizedFormatKey"]
+		if formatKey == nil {
+			continue
+		}
+		match := pluralVariable.FindStringSubmatchIndex(formatKey.text)
+		if match == nil {
+			c.Set(key, FromPrintf(formatKey.text))
+			continue
+		}
+		variable := entry.items[formatKey.text[match[2]:match[3]]]
+		if variable == nil || variable.kind != "dict" {
+			return nil, fmt.Errorf("stringsdict: %s: missing plural variable", key)
+		}
+		m := &catalog.Message{Key: key, Plurals: make(map[plural.Category]string)}
+		for _, category := range []plural.Category{plural.Zero, plural.One, plural.Two, plural.Few, plural.Many, plural.Other} {
+			form, ok := variable.items[string(category)]
+			if !ok {
+				continue
+			}
+			text := formatKey.text[:match[0]] + form.text + formatKey.text[match[1]:]
+			m.Plurals[category] = FromPrintf(text)
+		}
+		m.Text = m.Plurals[plural.Other]
+		c.Add(m)
+	}
+	return c, nil
+}
+
+//WriteStringsDict 写出Apple的.stringsdict文件，只包含含复数形式的消息，复数形式中的{0}作为数量写作%1$d
+func WriteStringsDict(w io.Writer, c *catalog.Catalog) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(xml.Header)
+	bw.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	bw.WriteString("<plist version=\"1.0\">\n<dict>\n")
+	for _, m := range c.Messages() {
+		if len(m.Plurals) == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "    <key>%s</key>\n    <dict>\n", escapeXML(m.Key))
+		bw.WriteString("        <key>NSStringLocalizedFormatKey</key>\n        <string>%#@count@</string>\n")
+		bw.WriteString("        <key>count</key>\n        <dict>\n")
+		bw.WriteString("            <key>NSStringFormatSpecTypeKey</key>\n            <string>NSStringPluralRuleType</string>\n")
+		bw.WriteString("            <key>NSStringFormatValueTypeKey</key>\n            <string>d</string>\n")
+		for _, category := range m.PluralCategories() {
+			text := ToPrintf(countPlaceholder(m.Plurals[category]), "@")
+			fmt.Fprintf(bw, "            <key>%s</key>\n            <string>%s</string>\n", category, escapeXML(text))
+		}
+		bw.WriteString("        </dict>\n    </dict>\n")
+	}
+	bw.WriteString("</dict>\n</plist>\n")
+	return bw.Flush()
+}
@@ -0,0 +1,340 @@
+package mobile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Khellendros97/khutils/catalog"
+	"github.com/Khellendros97/khutils/plural"
+)
+
+//icuArgument ICU MessageFormat中参数的名字，例如{name}、{count, plural, ...}
+var icuArgument = regexp.MustCompile(`\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*[,}]`)
+
+//argName 第index个参数在ARB中的名字
+func argName(index int) string {
+	return fmt.Sprintf("arg%d", index)
+}
+
+//toICU 将format包的占位符转换为ICU MessageFormat的命名参数，{0} => {arg0}
+//同时将参数编号对应的ARB类型记录到types中
+func toICU(text string, types map[int]string) string {
+	var sb strings.Builder
+	next := 0
+	last := 0
+	for _, span := range catalog.PlaceholderSpans(text) {
+		sb.WriteString(text[last:span[0]])
+		last = span[1]
+		ph := text[span[0]:span[1]]
+		if !strings.HasPrefix(ph, "{") || strings.HasPrefix(ph, "{{") {
+			sb.WriteString(ph)
+			continue
+		}
+		indexStr, spec, _ := strings.Cut(ph[1:len(ph)-1], ":")
+		index := next
+		if indexStr != "" {
+			n, err := strconv.Atoi(indexStr)
+			if err != nil {
+				sb.WriteString(ph)
+				continue
+			}
+			index = n
+		} else {
+			next++
+		}
+		typ := "Object"
+		switch {
+		case strings.HasPrefix(spec, "%") && strings.ContainsAny(spec[len(spec)-1:], "dxXob"):
+			typ = "int"
+		case strings.HasPrefix(spec, "%") && strings.ContainsAny(spec[len(spec)-1:], "feEgG"):
+			typ = "double"
+		case strings.HasPrefix(spec, "@"):
+			typ = "DateTime"
+		}
+		if old, ok := types[index]; !ok || old == "Object" {
+			types[index] = typ
+		}
+		sb.WriteString("{" + argName(index) + "}")
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+//fromICU 将ICU MessageFormat的命名参数转换为format包的占位符
+func fromICU(text string, indices map[string]int) string {
+	return icuArgument.ReplaceAllStringFunc(text, func(m string) string {
+		name := icuArgument.FindStringSubmatch(m)[1]
+		index, ok := indices[name]
+		if !ok || !strings.HasSuffix(m, "}") {
+			return m
+		}
+		return "{" + strconv.Itoa(index) + "}"
+	})
+}
+
+//argIndices 为ARB参数分配编号：全部形如argN时使用N，否则按声明和出现的顺序编号
+func argIndices(declared []string, text string) map[string]int {
+	names := append([]string{}, declared...)
+	seen := make(map[string]bool)
+	for _, name := range names {
+		seen[name] = true
+	}
+	for _, m := range icuArgument.FindAllStringSubmatch(text, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	indices := make(map[string]int)
+	for _, name := range names {
+		if !strings.HasPrefix(name, "arg") {
+			indices = nil
+			break
+		}
+		n, err := strconv.Atoi(name[3:])
+		if err != nil {
+			indices = nil
+			break
+		}
+		indices[name] = n
+	}
+	if indices == nil {
+		indices = make(map[string]int)
+		for i, name := range names {
+			indices[name] = i
+		}
+	}
+	return indices
+}
+
+//matchBrace 返回与text[start]处的{匹配的}的位置
+func matchBrace(text string, start int) int {
+	depth := 0
+	for i := start; i < len(text); i++ {
+		switch text[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+//parseICUPlural 解析整条消息为{count, plural, one{...} other{...}}形式时的各个复数形式
+func parseICUPlural(text string) (variable string, forms map[plural.Category]string, ok bool) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "{") || matchBrace(text, 0) != len(text)-1 {
+		return "", nil, false
+	}
+	parts := strings.SplitN(text[1:len(text)-1], ",", 3)
+	if len(parts) != 3 || strings.TrimSpace(parts[1]) != "plural" {
+		return "", nil, false
+	}
+	variable = strings.TrimSpace(parts[0])
+	rest := parts[2]
+	forms = make(map[plural.Category]string)
+	for {
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			return variable, forms, len(forms) > 0
+		}
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			return "", nil, false
+		}
+		selector := strings.TrimSpace(rest[:open])
+		end := matchBrace(rest, open)
+		if end < 0 {
+			return "", nil, false
+		}
+		body := rest[open+1 : end]
+		rest = rest[end+1:]
+		var category plural.Category
+		switch selector {
+		case "=0":
+			category = plural.Zero
+		case "=1":
+			category = plural.One
+		case "=2":
+			category = plural.Two
+		case "zero", "one", "two", "few", "many", "other":
+			category = plural.Category(selector)
+		default:
+			if strings.HasPrefix(selector, "offset:") {
+				continue
+			}
+			return "", nil, false
+		}
+		// 显式的=N与同名类别同时存在时，以类别为准
+		if _, exists := forms[category]; exists && strings.HasPrefix(selector, "=") {
+			continue
+		}
+		forms[category] = strings.ReplaceAll(body, "#", "{"+variable+"}")
+	}
+}
+
+//ReadARB 读取Flutter的.arb文件，@@locale为目录的语言，@key中的description为注释
+func ReadARB(r io.Reader) (*catalog.Catalog, error) {
+	decoder := json.NewDecoder(r)
+	if t, err := decoder.Token(); err != nil || t != json.Delim('{') {
+		return nil, fmt.Errorf("arb: expected object")
+	}
+	type metadata struct {
+		Description  string
+		Placeholders []string
+	}
+	c := catalog.New("")
+	metas := make(map[string]*metadata)
+	for decoder.More() {
+		t, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := t.(string)
+		switch {
+		case key == "@@locale":
+			if err := decoder.Decode(&c.Locale); err != nil {
+				return nil, fmt.Errorf("arb: @@locale: %w", err)
+			}
+		case strings.HasPrefix(key, "@@"):
+			var ignored json.RawMessage
+			decoder.Decode(&ignored)
+		case strings.HasPrefix(key, "@"):
+			var raw json.RawMessage
+			if err := decoder.Decode(&raw); err != nil {
+				return nil, err
+			}
+			meta := &metadata{}
+			var fields struct {
+				Description  string          `json:"description"`
+				Placeholders json.RawMessage `json:"placeholders"`
+			}
+			if err := json.Unmarshal(raw, &fields); err == nil {
+				meta.Description = fields.Description
+				meta.Placeholders = objectKeys(fields.Placeholders)
+			}
+			metas[key[1:]] = meta
+		default:
+			var text string
+			if err := decoder.Decode(&text); err != nil {
+				return nil, fmt.Errorf("arb: %s: %w", key, err)
+			}
+			c.Set(key, text)
+		}
+	}
+	for _, m := range c.Messages() {
+		var declared []string
+		if meta := metas[m.Key]; meta != nil {
+			m.Comment = meta.Description
+			declared = meta.Placeholders
+		}
+		indices := argIndices(declared, m.Text)
+		if _, forms, ok := parseICUPlural(m.Text); ok {
+			m.Plurals = make(map[plural.Category]string)
+			for category, text := range forms {
+				m.Plurals[category] = fromICU(text, indices)
+			}
+			m.Text = m.Plurals[plural.Other]
+			continue
+		}
+		m.Text = fromICU(m.Text, indices)
+	}
+	return c, nil
+}
+
+//objectKeys 按原始顺序返回JSON对象的键
+func objectKeys(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if t, err := decoder.Token(); err != nil || t != json.Delim('{') {
+		return nil
+	}
+	var keys []string
+	for decoder.More() {
+		t, err := decoder.Token()
+		if err != nil {
+			return keys
+		}
+		keys = append(keys, t.(string))
+		var ignored json.RawMessage
+		if err := decoder.Decode(&ignored); err != nil {
+			return keys
+		}
+	}
+	return keys
+}
+
+//WriteARB 写出Flutter的.arb文件，{0}写作{arg0}，复数形式写作{arg0, plural, ...}，第一个参数作为数量
+func WriteARB(w io.Writer, c *catalog.Catalog) error {
+	var buf bytes.Buffer
+	quote := func(s string) string {
+		var sb strings.Builder
+		encoder := json.NewEncoder(&sb)
+		encoder.SetEscapeHTML(false)
+		encoder.Encode(s)
+		return strings.TrimSuffix(sb.String(), "\n")
+	}
+	buf.WriteString("{\n")
+	fmt.Fprintf(&buf, "  \"@@locale\": %s", quote(c.Locale))
+	for _, m := range c.Messages() {
+		types := make(map[int]string)
+		var text string
+		if len(m.Plurals) > 0 {
+			types[0] = "num"
+			var sb strings.Builder
+			sb.WriteString("{" + argName(0) + ", plural,")
+			for _, category := range m.PluralCategories() {
+				sb.WriteString(" " + string(category) + "{" + toICU(m.Plurals[category], types) + "}")
+			}
+			sb.WriteString("}")
+			text = sb.String()
+		} else {
+			text = toICU(m.Text, types)
+		}
+		fmt.Fprintf(&buf, ",\n  %s: %s", quote(m.Key), quote(text))
+		if m.Comment == "" && len(types) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, ",\n  %s: {", quote("@"+m.Key))
+		sep := "\n"
+		if m.Comment != "" {
+			fmt.Fprintf(&buf, "%s    \"description\": %s", sep, quote(m.Comment))
+			sep = ",\n"
+		}
+		if len(types) > 0 {
+			fmt.Fprintf(&buf, "%s    \"placeholders\": {", sep)
+			for i, index := range sortedIndices(types) {
+				if i > 0 {
+					buf.WriteString(",")
+				}
+				fmt.Fprintf(&buf, "\n      %s: {\"type\": %s}", quote(argName(index)), quote(types[index]))
+			}
+			buf.WriteString("\n    }")
+		}
+		buf.WriteString("\n  }")
+	}
+	buf.WriteString("\n}\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func sortedIndices(types map[int]string) []int {
+	indices := make([]int, 0, len(types))
+	for index := range types {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+	return indices
+}
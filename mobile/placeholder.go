@@ -0,0 +1,101 @@
+package mobile
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Khellendros97/khutils/catalog"
+)
+
+//printfVerb printf风格的占位符，子匹配依次为：参数位置、标志和宽度精度、动词
+var printfVerb = regexp.MustCompile(`^%(?:(\d+)\$)?([-+#0]*(?:\d+)?(?:\.\d+)?)(l{0,2}[a-zA-Z@])`)
+
+//ToPrintf 将format包的占位符转换为printf风格的位置占位符，format包之外的%会转义为%%
+//{0} => %1$s（verb为s时），{1:%.2f} => %2$.2f，{} {}按顺序编号为%1$s %2$s。
+//其他格式化器（例如{0:@date}）无法在平台上表示，退化为%1$<verb>，{{...}}表达式原样保留
+func ToPrintf(text string, verb string) string {
+	var sb strings.Builder
+	next := 0
+	last := 0
+	for _, span := range catalog.PlaceholderSpans(text) {
+		sb.WriteString(strings.ReplaceAll(text[last:span[0]], "%", "%%"))
+		last = span[1]
+		ph := text[span[0]:span[1]]
+		if !strings.HasPrefix(ph, "{") || strings.HasPrefix(ph, "{{") {
+			sb.WriteString(ph)
+			continue
+		}
+		inner := ph[1 : len(ph)-1]
+		indexStr, spec, _ := strings.Cut(inner, ":")
+		index := next
+		if indexStr != "" {
+			n, err := strconv.Atoi(indexStr)
+			if err != nil {
+				sb.WriteString(ph)
+				continue
+			}
+			index = n
+		} else {
+			next++
+		}
+		if strings.HasPrefix(spec, "%") && len(spec) > 1 {
+			fmt.Fprintf(&sb, "%%%d$%s", index+1, spec[1:])
+		} else {
+			fmt.Fprintf(&sb, "%%%d$%s", index+1, verb)
+		}
+	}
+	sb.WriteString(strings.ReplaceAll(text[last:], "%", "%%"))
+	return sb.String()
+}
+
+//FromPrintf 将printf风格的占位符转换为format包的占位符，%%还原为%
+//%1$s、%1$@、%1$d => {0}，%2$.2f => {1:%.2f}，不带位置的%s %d按顺序编号为{0} {1}
+func FromPrintf(text string) string {
+	var sb strings.Builder
+	next := 0
+	for i := 0; i < len(text); i++ {
+		if text[i] != '%' {
+			sb.WriteByte(text[i])
+			continue
+		}
+		if strings.HasPrefix(text[i:], "%%") {
+			sb.WriteByte('%')
+			i++
+			continue
+		}
+		m := printfVerb.FindStringSubmatch(text[i:])
+		if m == nil {
+			sb.WriteByte('%')
+			continue
+		}
+		index := next
+		if m[1] != "" {
+			n, _ := strconv.Atoi(m[1])
+			index = n - 1
+		} else {
+			next++
+		}
+		spec, verb := m[2], strings.TrimLeft(m[3], "l")
+		switch {
+		case spec == "" && (verb == "s" || verb == "@" || verb == "d" || verb == "i" || verb == "u"):
+			fmt.Fprintf(&sb, "{%d}", index)
+		default:
+			switch verb {
+			case "@":
+				verb = "v"
+			case "i", "u":
+				verb = "d"
+			}
+			fmt.Fprintf(&sb, "{%d:%%%s%s}", index, spec, verb)
+		}
+		i += len(m[0]) - 1
+	}
+	return sb.String()
+}
+
+//countPlaceholder 复数形式中第一个参数是数量，在平台上应该作为整数格式化
+func countPlaceholder(text string) string {
+	return strings.ReplaceAll(text, "{0}", "{0:%d}")
+}
@@ -0,0 +1,279 @@
+package numeral
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Khellendros97/khutils/plural"
+)
+
+//System 数字系统，名字与CLDR的numbering system相同
+type System string
+
+const (
+	Latin                       System = "latn"     // 0123456789
+	FullWidth                   System = "fullwide" // ０１２３４５６７８９
+	ArabicIndic                 System = "arab"     // ٠١٢٣٤٥٦٧٨٩
+	ExtArabicIndic              System = "arabext"  // ۰۱۲۳۴۵۶۷۸۹
+	Devanagari                  System = "deva"     // ०१२३४५६७८९
+	Bengali                     System = "beng"     // ০১২৩৪৫৬৭৮৯
+	Thai                        System = "thai"     // ๐๑๒๓๔๕๖๗๘๙
+	HanDecimal                  System = "hanidec"  // 〇一二三四五六七八九，逐位替换
+	Roman                       System = "roman"    // MMXXIV
+	RomanLower                  System = "romanlow" // mmxxiv
+	Chinese                     System = "hans"     // 一千二百三十四
+	ChineseTraditional          System = "hant"     // 一千二百三十四，萬、億
+	ChineseFinancial            System = "hansfin"  // 壹仟贰佰叁拾肆
+	ChineseTraditionalFinancial System = "hantfin"  // 壹仟貳佰參拾肆
+)
+
+//digitSystem 逐位替换的数字系统
+type digitSystem struct {
+	digits  [10]rune
+	decimal rune // 小数点，0表示不替换
+	group   rune // 分组分隔符，0表示不替换
+}
+
+func digitRange(zero rune) (digits [10]rune) {
+	for i := range digits {
+		digits[i] = zero + rune(i)
+	}
+	return
+}
+
+var digitSystems = map[System]digitSystem{
+	Latin:          {digits: digitRange('0')},
+	FullWidth:      {digits: digitRange('０')},
+	ArabicIndic:    {digits: digitRange('٠'), decimal: '٫', group: '٬'},
+	ExtArabicIndic: {digits: digitRange('۰'), decimal: '٫', group: '٬'},
+	Devanagari:     {digits: digitRange('०')},
+	Bengali:        {digits: digitRange('০')},
+	Thai:           {digits: digitRange('๐')},
+	HanDecimal:     {digits: [10]rune{'〇', '一', '二', '三', '四', '五', '六', '七', '八', '九'}},
+}
+
+//ParseSystem 解析数字系统的名字
+func ParseSystem(name string) (System, bool) {
+	system := System(strings.ToLower(name))
+	if _, ok := digitSystems[system]; ok {
+		return system, true
+	}
+	if _, ok := chineseSystems[system]; ok {
+		return system, true
+	}
+	if system == Roman || system == RomanLower {
+		return system, true
+	}
+	return "", false
+}
+
+//IsDigitSystem 数字系统是否是逐位替换的，这样的系统可以表示任意十进制数字字符串
+func IsDigitSystem(system System) bool {
+	_, ok := digitSystems[system]
+	return ok
+}
+
+//Digits 将十进制数字字符串中的ASCII数字替换为数字系统中的数字，例如Digits("12.5", Devanagari) => "१२.५"
+//阿拉伯-印度数字同时替换小数点和分组分隔符
+func Digits(s string, system System) (string, error) {
+	ds, ok := digitSystems[system]
+	if !ok {
+		return "", fmt.Errorf("not a digit system: %s", system)
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9':
+			return ds.digits[r-'0']
+		case r == '.' && ds.decimal != 0:
+			return ds.decimal
+		case r == ',' && ds.group != 0:
+			return ds.group
+		}
+		return r
+	}, s), nil
+}
+
+//Group 在十进制数字字符串的整数部分每3位插入分隔符，例如Group("-1234567.89", ",") => "-1,234,567.89"
+func Group(s string, sep string) string {
+	start := strings.IndexFunc(s, func(r rune) bool { return r >= '0' && r <= '9' })
+	if start < 0 {
+		return s
+	}
+	end := start
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	digits := s[start:end]
+	var sb strings.Builder
+	sb.WriteString(s[:start])
+	for i := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteString(sep)
+		}
+		sb.WriteByte(digits[i])
+	}
+	sb.WriteString(s[end:])
+	return sb.String()
+}
+
+var romanNumerals = []struct {
+	value  int64
+	symbol string
+}{
+	{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"}, {100, "C"}, {90, "XC"},
+	{50, "L"}, {40, "XL"}, {10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+}
+
+//RomanNumeral 将1到3999之间的整数转换为罗马数字，例如RomanNumeral(2024) => "MMXXIV"
+func RomanNumeral(n int64) (string, error) {
+	if n < 1 || n > 3999 {
+		return "", fmt.Errorf("roman numeral out of range: %d", n)
+	}
+	var sb strings.Builder
+	for _, r := range romanNumerals {
+		for ; n >= r.value; n -= r.value {
+			sb.WriteString(r.symbol)
+		}
+	}
+	return sb.String(), nil
+}
+
+//chineseSystem 中文数字使用的字符
+type chineseSystem struct {
+	digits    [10]string
+	units     [4]string // 个、十、百、千
+	sections  []string  // 个、万、亿、兆、京
+	financial bool      // 大写数字不省略十前面的一
+	minus     string
+}
+
+var chineseSystems = map[System]chineseSystem{
+	Chinese: {
+		digits:   [10]string{"零", "一", "二", "三", "四", "五", "六", "七", "八", "九"},
+		units:    [4]string{"", "十", "百", "千"},
+		sections: []string{"", "万", "亿", "兆", "京"},
+		minus:    "负",
+	},
+	ChineseTraditional: {
+		digits:   [10]string{"零", "一", "二", "三", "四", "五", "六", "七", "八", "九"},
+		units:    [4]string{"", "十", "百", "千"},
+		sections: []string{"", "萬", "億", "兆", "京"},
+		minus:    "負",
+	},
+	ChineseFinancial: {
+		digits:    [10]string{"零", "壹", "贰", "叁", "肆", "伍", "陆", "柒", "捌", "玖"},
+		units:     [4]string{"", "拾", "佰", "仟"},
+		sections:  []string{"", "万", "亿", "兆", "京"},
+		financial: true,
+		minus:     "负",
+	},
+	ChineseTraditionalFinancial: {
+		digits:    [10]string{"零", "壹", "貳", "參", "肆", "伍", "陸", "柒", "捌", "玖"},
+		units:     [4]string{"", "拾", "佰", "仟"},
+		sections:  []string{"", "萬", "億", "兆", "京"},
+		financial: true,
+		minus:     "負",
+	},
+}
+
+//section 转换0到9999之间的数，中间连续的0读作一个零，末尾的0不读
+func (c chineseSystem) section(n int64) string {
+	var sb strings.Builder
+	zero := false
+	for pos := 3; pos >= 0; pos-- {
+		digit := n
+		for i := 0; i < pos; i++ {
+			digit /= 10
+		}
+		digit %= 10
+		if digit == 0 {
+			zero = sb.Len() > 0
+			continue
+		}
+		if zero {
+			sb.WriteString(c.digits[0])
+			zero = false
+		}
+		sb.WriteString(c.digits[digit] + c.units[pos])
+	}
+	return sb.String()
+}
+
+//ChineseNumeral 将整数转换为中文数字，例如ChineseNumeral(10203, Chinese) => "一万零二百零三"
+func ChineseNumeral(n int64, system System) (string, error) {
+	c, ok := chineseSystems[system]
+	if !ok {
+		return "", fmt.Errorf("not a chinese numeral system: %s", system)
+	}
+	if n == 0 {
+		return c.digits[0], nil
+	}
+	sign := ""
+	u := uint64(n)
+	if n < 0 {
+		sign, u = c.minus, uint64(-n)
+	}
+	var sections []int64
+	for ; u > 0; u /= 10000 {
+		sections = append(sections, int64(u%10000))
+	}
+	var sb strings.Builder
+	zero := false
+	for i := len(sections) - 1; i >= 0; i-- {
+		if sections[i] == 0 {
+			zero = true
+			continue
+		}
+		// 跳过的节或不满千的节前面读一个零
+		if sb.Len() > 0 && (zero || sections[i] < 1000) {
+			sb.WriteString(c.digits[0])
+		}
+		zero = false
+		sb.WriteString(c.section(sections[i]) + c.sections[i])
+	}
+	s := sb.String()
+	// 十到十九开头时省略一，例如十二、十万
+	if !c.financial && sections[len(sections)-1] >= 10 && sections[len(sections)-1] < 20 {
+		s = strings.TrimPrefix(s, c.digits[1])
+	}
+	return sign + s, nil
+}
+
+//ordinalPatterns 序数的模式，{0}为数字，按序数复数类别选择
+var ordinalPatterns = map[string]map[plural.Category]string{
+	"en": {plural.One: "{0}st", plural.Two: "{0}nd", plural.Few: "{0}rd", plural.Other: "{0}th"},
+	"fr": {plural.One: "{0}er", plural.Other: "{0}e"},
+	"de": {plural.Other: "{0}."},
+	"es": {plural.Other: "{0}.º"},
+	"it": {plural.Other: "{0}º"},
+	"pt": {plural.Other: "{0}º"},
+	"ru": {plural.Other: "{0}-й"},
+	"nl": {plural.Other: "{0}e"},
+	"zh": {plural.Other: "第{0}"},
+	"ja": {plural.Other: "第{0}"},
+	"ko": {plural.Other: "제{0}"},
+}
+
+//Ordinal 按语言的序数复数规则为数字加上序数标记，未知语言使用英语，例如Ordinal("en", 22, "22") => "22nd"、Ordinal("zh", 1, "1") => "第1"
+//@params value 用于选择复数类别的数值; digits 已经格式化的数字，可以是分组的或其他数字系统的
+func Ordinal(locale string, value any, digits string) string {
+	patterns, ok := ordinalPatterns[plural.Language(locale)]
+	if !ok {
+		// 没有模式的语言使用英语的模式和规则
+		locale, patterns = "en", ordinalPatterns["en"]
+	}
+	pattern, ok := patterns[plural.Ordinal(locale, value)]
+	if !ok {
+		pattern = patterns[plural.Other]
+	}
+	return strings.ReplaceAll(pattern, "{0}", digits)
+}
+
+//SetOrdinalPattern 注册或覆盖一个语言在某个序数复数类别下的模式，例如SetOrdinalPattern("en", plural.One, "{0}st")
+func SetOrdinalPattern(language string, category plural.Category, pattern string) {
+	language = plural.Language(language)
+	if ordinalPatterns[language] == nil {
+		ordinalPatterns[language] = make(map[plural.Category]string)
+	}
+	ordinalPatterns[language][category] = pattern
+}
@@ -0,0 +1,361 @@
+package plural
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+//Category CLDR复数类别
+type Category string
+
+const (
+	Zero  Category = "zero"
+	One   Category = "one"
+	Two   Category = "two"
+	Few   Category = "few"
+	Many  Category = "many"
+	Other Category = "other"
+)
+
+//Operands CLDR复数规则使用的操作数，参见UTS #35 Plural Operand Meanings
+type Operands struct {
+	N float64 // 绝对值
+	I int64   // 整数部分
+	V int     // 小数位数（含末尾的0）
+	W int     // 小数位数（不含末尾的0）
+	F int64   // 小数部分（含末尾的0）
+	T int64   // 小数部分（不含末尾的0）
+}
+
+//NewOperands 从数值或十进制字符串计算操作数，字符串可以保留末尾的0，例如"1.50"的V为2
+func NewOperands(value any) (Operands, error) {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = strings.TrimSpace(v)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		s = fmt.Sprintf("%d", v)
+	case float32:
+		s = strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		return Operands{}, fmt.Errorf("invalid plural operand: %v", value)
+	}
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" {
+		intPart = "0"
+	}
+	var o Operands
+	var err error
+	if o.N, err = strconv.ParseFloat(s, 64); err != nil {
+		return Operands{}, fmt.Errorf("invalid plural operand: %s", s)
+	}
+	if o.I, err = strconv.ParseInt(intPart, 10, 64); err != nil {
+		// 超出int64范围的整数只保留低位，足以用于取模运算
+		o.I = int64(math.Mod(math.Trunc(o.N), 1e18))
+	}
+	o.V = len(fracPart)
+	trimmed := strings.TrimRight(fracPart, "0")
+	o.W = len(trimmed)
+	if o.V > 0 {
+		o.F, _ = strconv.ParseInt(fracPart, 10, 64)
+	}
+	if o.W > 0 {
+		o.T, _ = strconv.ParseInt(trimmed, 10, 64)
+	}
+	return o, nil
+}
+
+//isInt n是否为整数值（例如1.0）
+func (o Operands) isInt() bool {
+	return o.N == math.Trunc(o.N)
+}
+
+//Rule 复数规则
+type Rule func(o Operands) Category
+
+//Language 返回语言标签的基础语言，zh-Hant-TW => zh
+func Language(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	return locale
+}
+
+//CardinalRule 返回语言的基数复数规则，未知语言使用英语规则
+func CardinalRule(locale string) Rule {
+	if rule, ok := cardinalRules[Language(locale)]; ok {
+		return rule
+	}
+	return cardinalOneIV
+}
+
+//Cardinal 返回数值在该语言下的基数复数类别
+func Cardinal(locale string, value any) Category {
+	o, err := NewOperands(value)
+	if err != nil {
+		return Other
+	}
+	return CardinalRule(locale)(o)
+}
+
+//SetCardinalRule 注册或覆盖一个语言的基数复数规则
+func SetCardinalRule(language string, rule Rule) {
+	cardinalRules[Language(language)] = rule
+}
+
+//OrdinalRule 返回语言的序数复数规则，未知语言使用英语规则
+func OrdinalRule(locale string) Rule {
+	if rule, ok := ordinalRules[Language(locale)]; ok {
+		return rule
+	}
+	return ordinalEnglish
+}
+
+//Ordinal 返回数值在该语言下的序数复数类别，例如英语中1为one（1st）、2为two（2nd）
+func Ordinal(locale string, value any) Category {
+	o, err := NewOperands(value)
+	if err != nil {
+		return Other
+	}
+	return OrdinalRule(locale)(o)
+}
+
+//SetOrdinalRule 注册或覆盖一个语言的序数复数规则
+func SetOrdinalRule(language string, rule Rule) {
+	ordinalRules[Language(language)] = rule
+}
+
+func inRange(v int64, lo int64, hi int64) bool {
+	return v >= lo && v <= hi
+}
+
+func cardinalOther(o Operands) Category {
+	return Other
+}
+
+//cardinalOneIV one: i = 1 and v = 0
+func cardinalOneIV(o Operands) Category {
+	if o.I == 1 && o.V == 0 {
+		return One
+	}
+	return Other
+}
+
+//cardinalOneN one: n = 1
+func cardinalOneN(o Operands) Category {
+	if o.N == 1 {
+		return One
+	}
+	return Other
+}
+
+//cardinalOneI01 one: i = 0,1
+func cardinalOneI01(o Operands) Category {
+	if o.I == 0 || o.I == 1 {
+		return One
+	}
+	return Other
+}
+
+//cardinalOneI0N1 one: i = 0 or n = 1
+func cardinalOneI0N1(o Operands) Category {
+	if o.I == 0 || o.N == 1 {
+		return One
+	}
+	return Other
+}
+
+func cardinalFrench(o Operands) Category {
+	if o.I == 0 || o.I == 1 {
+		return One
+	}
+	if o.V == 0 && o.I != 0 && o.I%1000000 == 0 {
+		return Many
+	}
+	return Other
+}
+
+func cardinalEastSlavic(o Operands) Category {
+	if o.V != 0 {
+		return Other
+	}
+	i10, i100 := o.I%10, o.I%100
+	switch {
+	case i10 == 1 && i100 != 11:
+		return One
+	case inRange(i10, 2, 4) && !inRange(i100, 12, 14):
+		return Few
+	default:
+		return Many
+	}
+}
+
+func cardinalPolish(o Operands) Category {
+	if o.V != 0 {
+		return Other
+	}
+	i10, i100 := o.I%10, o.I%100
+	switch {
+	case o.I == 1:
+		return One
+	case inRange(i10, 2, 4) && !inRange(i100, 12, 14):
+		return Few
+	default:
+		return Many
+	}
+}
+
+func cardinalCzech(o Operands) Category {
+	switch {
+	case o.V != 0:
+		return Many
+	case o.I == 1:
+		return One
+	case inRange(o.I, 2, 4):
+		return Few
+	}
+	return Other
+}
+
+func cardinalArabic(o Operands) Category {
+	if o.isInt() {
+		n100 := int64(math.Mod(o.N, 100))
+		switch {
+		case o.N == 0:
+			return Zero
+		case o.N == 1:
+			return One
+		case o.N == 2:
+			return Two
+		case inRange(n100, 3, 10):
+			return Few
+		case inRange(n100, 11, 99):
+			return Many
+		}
+	}
+	return Other
+}
+
+func cardinalHebrew(o Operands) Category {
+	switch {
+	case (o.I == 1 && o.V == 0) || (o.I == 0 && o.V != 0):
+		return One
+	case o.I == 2 && o.V == 0:
+		return Two
+	}
+	return Other
+}
+
+var cardinalRules = map[string]Rule{
+	"ja": cardinalOther, "zh": cardinalOther, "ko": cardinalOther, "th": cardinalOther,
+	"vi": cardinalOther, "id": cardinalOther, "ms": cardinalOther, "my": cardinalOther,
+	"lo": cardinalOther, "km": cardinalOther, "yue": cardinalOther,
+
+	"en": cardinalOneIV, "de": cardinalOneIV, "nl": cardinalOneIV, "sv": cardinalOneIV,
+	"it": cardinalOneIV, "fi": cardinalOneIV, "et": cardinalOneIV, "ca": cardinalOneIV,
+	"gl": cardinalOneIV, "ur": cardinalOneIV,
+
+	"es": cardinalOneN, "tr": cardinalOneN, "el": cardinalOneN, "hu": cardinalOneN,
+	"nb": cardinalOneN, "no": cardinalOneN, "da": cardinalOneN, "bg": cardinalOneN,
+
+	"pt": cardinalOneI01,
+	"hi": cardinalOneI0N1, "bn": cardinalOneI0N1, "fa": cardinalOneI0N1,
+	"fr": cardinalFrench,
+	"ru": cardinalEastSlavic, "uk": cardinalEastSlavic, "be": cardinalEastSlavic,
+	"pl": cardinalPolish,
+	"cs": cardinalCzech, "sk": cardinalCzech,
+	"ar": cardinalArabic,
+	"he": cardinalHebrew,
+}
+
+func ordinalEnglish(o Operands) Category {
+	n10, n100 := o.I%10, o.I%100
+	switch {
+	case !o.isInt():
+		return Other
+	case n10 == 1 && n100 != 11:
+		return One
+	case n10 == 2 && n100 != 12:
+		return Two
+	case n10 == 3 && n100 != 13:
+		return Few
+	}
+	return Other
+}
+
+//ordinalOneN1 one: n = 1
+func ordinalOneN1(o Operands) Category {
+	if o.N == 1 {
+		return One
+	}
+	return Other
+}
+
+//ordinalItalian many: n = 11,8,80,800
+func ordinalItalian(o Operands) Category {
+	switch o.N {
+	case 11, 8, 80, 800:
+		return Many
+	}
+	return Other
+}
+
+//ordinalSwedish one: n % 10 = 1,2 and n % 100 != 11,12
+func ordinalSwedish(o Operands) Category {
+	n10, n100 := o.I%10, o.I%100
+	if o.isInt() && (n10 == 1 || n10 == 2) && n100 != 11 && n100 != 12 {
+		return One
+	}
+	return Other
+}
+
+func ordinalCatalan(o Operands) Category {
+	switch o.N {
+	case 1, 3:
+		return One
+	case 2:
+		return Two
+	case 4:
+		return Few
+	}
+	return Other
+}
+
+func ordinalHindi(o Operands) Category {
+	switch o.N {
+	case 1:
+		return One
+	case 2, 3:
+		return Two
+	case 4:
+		return Few
+	case 6:
+		return Many
+	}
+	return Other
+}
+
+var ordinalRules = map[string]Rule{
+	"ja": cardinalOther, "zh": cardinalOther, "ko": cardinalOther, "th": cardinalOther,
+	"id": cardinalOther, "yue": cardinalOther, "de": cardinalOther,
+	"nl": cardinalOther, "es": cardinalOther, "pt": cardinalOther, "fi": cardinalOther,
+	"et": cardinalOther, "da": cardinalOther, "nb": cardinalOther, "no": cardinalOther,
+	"tr": cardinalOther, "el": cardinalOther, "ru": cardinalOther, "uk": cardinalOther,
+	"pl": cardinalOther, "cs": cardinalOther, "sk": cardinalOther, "ar": cardinalOther,
+	"he": cardinalOther, "fa": cardinalOther,
+
+	"en": ordinalEnglish,
+	"fr": ordinalOneN1, "ms": ordinalOneN1, "vi": ordinalOneN1,
+	"it": ordinalItalian,
+	"sv": ordinalSwedish,
+	"ca": ordinalCatalan,
+	"hi": ordinalHindi, "bn": ordinalHindi,
+}
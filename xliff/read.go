@@ -0,0 +1,219 @@
+package xliff
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Khellendros97/khutils/catalog"
+)
+
+//node 简单的XML元素树，用于读取含有内联标记的混合内容
+type node struct {
+	name     string
+	attrs    map[string]string
+	children []any // *node或string
+}
+
+func (n *node) attr(name string) string {
+	return n.attrs[name]
+}
+
+func (n *node) elements(name string) []*node {
+	var result []*node
+	for _, child := range n.children {
+		if el, ok := child.(*node); ok && el.name == name {
+			result = append(result, el)
+		}
+	}
+	return result
+}
+
+func (n *node) element(name string) *node {
+	if elements := n.elements(name); len(elements) > 0 {
+		return elements[0]
+	}
+	return nil
+}
+
+//descendants 深度优先返回所有名为name的后代元素
+func (n *node) descendants(name string, result []*node) []*node {
+	for _, child := range n.children {
+		if el, ok := child.(*node); ok {
+			if el.name == name {
+				result = append(result, el)
+			} else {
+				result = el.descendants(name, result)
+			}
+		}
+	}
+	return result
+}
+
+func (n *node) text() string {
+	var sb strings.Builder
+	for _, child := range n.children {
+		switch v := child.(type) {
+		case string:
+			sb.WriteString(v)
+		case *node:
+			sb.WriteString(v.text())
+		}
+	}
+	return sb.String()
+}
+
+func parseTree(r io.Reader) (*node, error) {
+	decoder := xml.NewDecoder(r)
+	root := &node{}
+	stack := []*node{root}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		top := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			el := &node{name: t.Name.Local, attrs: make(map[string]string)}
+			for _, a := range t.Attr {
+				el.attrs[a.Name.Local] = a.Value
+			}
+			top.children = append(top.children, el)
+			stack = append(stack, el)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			top.children = append(top.children, string(t))
+		}
+	}
+	if root = root.element("xliff"); root == nil {
+		return nil, fmt.Errorf("not an xliff document")
+	}
+	return root, nil
+}
+
+//content 还原source或target中的文本，内联标记替换为其表示的占位符
+func content(n *node, data map[string]string) string {
+	if n == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, child := range n.children {
+		switch v := child.(type) {
+		case string:
+			sb.WriteString(v)
+		case *node:
+			switch v.name {
+			case "ph":
+				if ref := v.attr("dataRef"); ref != "" {
+					sb.WriteString(data[ref])
+				} else if equiv := v.attr("equiv"); equiv != "" {
+					sb.WriteString(equiv)
+				} else {
+					sb.WriteString(v.text())
+				}
+			case "x", "bx", "ex":
+				sb.WriteString(v.attr("equiv-text"))
+			case "sc", "ec":
+				if ref := v.attr("dataRef"); ref != "" {
+					sb.WriteString(data[ref])
+				}
+			default: // g、mrk、pc等成对的标记保留其中的文本
+				sb.WriteString(content(v, data))
+			}
+		}
+	}
+	return sb.String()
+}
+
+//Read 读取XLIFF 1.2或2.0文档
+func Read(r io.Reader) (*Document, error) {
+	root, err := parseTree(r)
+	if err != nil {
+		return nil, err
+	}
+	doc := &Document{Version: root.attr("version")}
+	switch doc.Version {
+	case VERSION_12:
+		for _, file := range root.elements("file") {
+			doc.SourceLanguage = file.attr("source-language")
+			doc.TargetLanguage = file.attr("target-language")
+			doc.Original = file.attr("original")
+			for _, tu := range file.descendants("trans-unit", nil) {
+				u := &Unit{ID: tu.attr("id"), Key: tu.attr("resname")}
+				if u.Key == "" {
+					u.Key = u.ID
+				}
+				u.Source = content(tu.element("source"), nil)
+				u.Target = content(tu.element("target"), nil)
+				for _, ctx := range tu.descendants("context", nil) {
+					if ctx.attr("context-type") == "x-msgctxt" {
+						u.Context = ctx.text()
+					}
+				}
+				var notes []string
+				for _, note := range tu.elements("note") {
+					notes = append(notes, note.text())
+				}
+				u.Note = strings.Join(notes, "\n")
+				doc.Units = append(doc.Units, u)
+			}
+		}
+	case VERSION_20:
+		doc.SourceLanguage = root.attr("srcLang")
+		doc.TargetLanguage = root.attr("trgLang")
+		for _, file := range root.elements("file") {
+			doc.Original = file.attr("id")
+			for _, unit := range file.descendants("unit", nil) {
+				u := &Unit{ID: unit.attr("id"), Key: unit.attr("name")}
+				if u.Key == "" {
+					u.Key = u.ID
+				}
+				data := make(map[string]string)
+				if original := unit.element("originalData"); original != nil {
+					for _, d := range original.elements("data") {
+						data[d.attr("id")] = d.text()
+					}
+				}
+				var notes []string
+				if n := unit.element("notes"); n != nil {
+					for _, note := range n.elements("note") {
+						if note.attr("category") == "msgctxt" {
+							u.Context = note.text()
+						} else {
+							notes = append(notes, note.text())
+						}
+					}
+				}
+				u.Note = strings.Join(notes, "\n")
+				var source, target strings.Builder
+				for _, child := range unit.children {
+					if seg, ok := child.(*node); ok && (seg.name == "segment" || seg.name == "ignorable") {
+						source.WriteString(content(seg.element("source"), data))
+						target.WriteString(content(seg.element("target"), data))
+					}
+				}
+				u.Source, u.Target = source.String(), target.String()
+				doc.Units = append(doc.Units, u)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported xliff version: %s", doc.Version)
+	}
+	return doc, nil
+}
+
+//Import 读取翻译后的XLIFF文档并转换为目标语言的目录
+//译文丢失或多出占位符时，仍然返回转换后的目录，同时返回由*PlaceholderError组成的错误
+func Import(r io.Reader) (*catalog.Catalog, error) {
+	doc, err := Read(r)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Catalog(), doc.Validate()
+}
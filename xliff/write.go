@@ -0,0 +1,147 @@
+package xliff
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Khellendros97/khutils/catalog"
+)
+
+func escape(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
+
+func attr(name string, value string) string {
+	if value == "" {
+		return ""
+	}
+	return " " + name + `="` + escape(value) + `"`
+}
+
+//inlineCodes 为单元中的占位符分配编号，相同的占位符在原文和译文中使用相同的编号
+type inlineCodes struct {
+	ids   map[string]int // 占位符文本及其第几次出现 => 编号
+	codes []string       // 编号-1 => 占位符文本
+}
+
+func newInlineCodes() *inlineCodes {
+	return &inlineCodes{ids: make(map[string]int)}
+}
+
+//markup 将文本转换为带有占位符标记的XML内容，ph根据编号和占位符文本生成标记
+func (c *inlineCodes) markup(text string, ph func(id int, code string) string) string {
+	var sb strings.Builder
+	seen := make(map[string]int)
+	last := 0
+	for _, span := range catalog.PlaceholderSpans(text) {
+		sb.WriteString(escape(text[last:span[0]]))
+		code := text[span[0]:span[1]]
+		seen[code]++
+		occurrence := fmt.Sprintf("%s\x00%d", code, seen[code])
+		id, ok := c.ids[occurrence]
+		if !ok {
+			c.codes = append(c.codes, code)
+			id = len(c.codes)
+			c.ids[occurrence] = id
+		}
+		sb.WriteString(ph(id, code))
+		last = span[1]
+	}
+	sb.WriteString(escape(text[last:]))
+	return sb.String()
+}
+
+//Write 按文档的版本写出XLIFF
+func (d *Document) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(xml.Header)
+	switch d.Version {
+	case VERSION_12, "":
+		d.write12(bw)
+	case VERSION_20:
+		d.write20(bw)
+	default:
+		return fmt.Errorf("unsupported xliff version: %s", d.Version)
+	}
+	return bw.Flush()
+}
+
+func (d *Document) write12(w *bufio.Writer) {
+	fmt.Fprintf(w, "<xliff version=\"1.2\" xmlns=\"%s\">\n", namespace12)
+	fmt.Fprintf(w, "  <file%s%s%s datatype=\"plaintext\">\n", attr("original", d.Original),
+		attr("source-language", d.SourceLanguage), attr("target-language", d.TargetLanguage))
+	w.WriteString("    <body>\n")
+	for _, u := range d.Units {
+		codes := newInlineCodes()
+		ph := func(id int, code string) string {
+			return fmt.Sprintf(`<ph id="%d">%s</ph>`, id, escape(code))
+		}
+		fmt.Fprintf(w, "      <trans-unit%s%s>\n", attr("id", u.ID), attr("resname", u.Key))
+		fmt.Fprintf(w, "        <source>%s</source>\n", codes.markup(u.Source, ph))
+		if u.Target != "" {
+			fmt.Fprintf(w, "        <target state=\"translated\">%s</target>\n", codes.markup(u.Target, ph))
+		} else if d.TargetLanguage != "" {
+			w.WriteString("        <target state=\"needs-translation\"></target>\n")
+		}
+		if u.Context != "" {
+			fmt.Fprintf(w, "        <context-group purpose=\"information\"><context context-type=\"x-msgctxt\">%s</context></context-group>\n", escape(u.Context))
+		}
+		if u.Note != "" {
+			fmt.Fprintf(w, "        <note>%s</note>\n", escape(u.Note))
+		}
+		w.WriteString("      </trans-unit>\n")
+	}
+	w.WriteString("    </body>\n  </file>\n</xliff>\n")
+}
+
+func (d *Document) write20(w *bufio.Writer) {
+	fmt.Fprintf(w, "<xliff xmlns=\"%s\" version=\"2.0\"%s%s>\n", namespace20,
+		attr("srcLang", d.SourceLanguage), attr("trgLang", d.TargetLanguage))
+	original := d.Original
+	if original == "" {
+		original = "f1"
+	}
+	fmt.Fprintf(w, "  <file%s>\n", attr("id", original))
+	for _, u := range d.Units {
+		codes := newInlineCodes()
+		ph := func(id int, code string) string {
+			return fmt.Sprintf(`<ph id="%d" dataRef="d%d"/>`, id, id)
+		}
+		source := codes.markup(u.Source, ph)
+		target := codes.markup(u.Target, ph)
+		fmt.Fprintf(w, "    <unit%s%s>\n", attr("id", u.ID), attr("name", u.Key))
+		if u.Context != "" || u.Note != "" {
+			w.WriteString("      <notes>\n")
+			if u.Context != "" {
+				fmt.Fprintf(w, "        <note category=\"msgctxt\">%s</note>\n", escape(u.Context))
+			}
+			if u.Note != "" {
+				fmt.Fprintf(w, "        <note>%s</note>\n", escape(u.Note))
+			}
+			w.WriteString("      </notes>\n")
+		}
+		if len(codes.codes) > 0 {
+			w.WriteString("      <originalData>\n")
+			for i, code := range codes.codes {
+				fmt.Fprintf(w, "        <data id=\"d%d\">%s</data>\n", i+1, escape(code))
+			}
+			w.WriteString("      </originalData>\n")
+		}
+		state := "initial"
+		if u.Target != "" {
+			state = "translated"
+		}
+		fmt.Fprintf(w, "      <segment state=\"%s\">\n", state)
+		fmt.Fprintf(w, "        <source>%s</source>\n", source)
+		if u.Target != "" {
+			fmt.Fprintf(w, "        <target>%s</target>\n", target)
+		}
+		w.WriteString("      </segment>\n    </unit>\n")
+	}
+	w.WriteString("  </file>\n</xliff>\n")
+}
@@ -0,0 +1,185 @@
+package xliff
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Khellendros97/khutils/bundle"
+	"github.com/Khellendros97/khutils/catalog"
+	"github.com/Khellendros97/khutils/plural"
+)
+
+const (
+	VERSION_12 = "1.2"
+	VERSION_20 = "2.0"
+
+	namespace12 = "urn:oasis:names:tc:xliff:document:1.2"
+	namespace20 = "urn:oasis:names:tc:xliff:document:2.0"
+)
+
+//Unit 一个待翻译的单元
+//复数形式的每种类别各占一个单元，Key写作key[one]、key[other]等形式
+type Unit struct {
+	ID      string
+	Key     string
+	Context string
+	Source  string
+	Target  string
+	Note    string
+}
+
+//Document XLIFF文档
+type Document struct {
+	Version        string // VERSION_12或VERSION_20
+	SourceLanguage string
+	TargetLanguage string
+	Original       string // 1.2中file的original属性，2.0中file的id
+	Units          []*Unit
+}
+
+//pluralKey 复数形式单元的键
+func pluralKey(key string, category plural.Category) string {
+	return key + "[" + string(category) + "]"
+}
+
+//splitPluralKey 拆分复数形式单元的键，不是复数形式时category为空
+func splitPluralKey(key string) (string, plural.Category) {
+	if !strings.HasSuffix(key, "]") {
+		return key, ""
+	}
+	i := strings.LastIndexByte(key, '[')
+	if i < 0 {
+		return key, ""
+	}
+	category := plural.Category(key[i+1 : len(key)-1])
+	switch category {
+	case plural.Zero, plural.One, plural.Two, plural.Few, plural.Many, plural.Other:
+		return key[:i], category
+	}
+	return key, ""
+}
+
+//Export 导出翻译单元，source为源语言的目录，target为目标语言已有的翻译（可以为nil）
+func Export(source *catalog.Catalog, target *catalog.Catalog, version string) *Document {
+	doc := &Document{
+		Version:        version,
+		SourceLanguage: source.Locale,
+		Original:       "messages",
+	}
+	if target != nil {
+		doc.TargetLanguage = target.Locale
+	}
+	add := func(key string, context string, sourceText string, targetText string, note string) {
+		doc.Units = append(doc.Units, &Unit{
+			ID:      fmt.Sprintf("u%d", len(doc.Units)+1),
+			Key:     key,
+			Context: context,
+			Source:  sourceText,
+			Target:  targetText,
+			Note:    note,
+		})
+	}
+	for _, m := range source.Messages() {
+		var t *catalog.Message
+		if target != nil {
+			t = target.GetContext(m.Context, m.Key)
+		}
+		if len(m.Plurals) == 0 {
+			targetText := ""
+			if t != nil {
+				targetText = t.Text
+			}
+			add(m.Key, m.Context, m.Text, targetText, m.Comment)
+			continue
+		}
+		// 目标语言的复数类别可能与源语言不同，按两者的并集导出
+		categories := m.PluralCategories()
+		if t != nil {
+			for _, category := range t.PluralCategories() {
+				if _, ok := m.Plurals[category]; !ok {
+					categories = append(categories, category)
+				}
+			}
+		}
+		for _, category := range categories {
+			sourceText, ok := m.Plurals[category]
+			if !ok {
+				sourceText = m.Plurals[plural.Other]
+			}
+			targetText := ""
+			if t != nil {
+				targetText = t.Plurals[category]
+			}
+			add(pluralKey(m.Key, category), m.Context, sourceText, targetText, m.Comment)
+		}
+	}
+	return doc
+}
+
+//ExportBundle 导出消息包中源语言的全部键，以及目标语言已有的翻译
+func ExportBundle(b *bundle.Bundle, sourceLocale string, targetLocale string, version string) *Document {
+	return Export(b.Catalog(sourceLocale), b.Catalog(targetLocale), version)
+}
+
+//Catalog 将文档中的翻译转换为目标语言的目录，未翻译的单元会被忽略
+func (d *Document) Catalog() *catalog.Catalog {
+	c := catalog.New(d.TargetLanguage)
+	for _, u := range d.Units {
+		if u.Target == "" {
+			continue
+		}
+		key, category := splitPluralKey(u.Key)
+		m := c.GetContext(u.Context, key)
+		if m == nil {
+			m = &catalog.Message{Key: key, Context: u.Context, Comment: u.Note}
+			c.Add(m)
+		}
+		if category == "" {
+			m.Text = u.Target
+			continue
+		}
+		if m.Plurals == nil {
+			m.Plurals = make(map[plural.Category]string)
+		}
+		m.Plurals[category] = u.Target
+		if category == plural.Other {
+			m.Text = u.Target
+		}
+	}
+	return c
+}
+
+//PlaceholderError 译文缺少或多出了原文中的占位符
+type PlaceholderError struct {
+	Key     string
+	Missing []string
+	Extra   []string
+}
+
+func (e *PlaceholderError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("placeholder mismatch in " + e.Key)
+	if len(e.Missing) > 0 {
+		sb.WriteString(", missing: " + strings.Join(e.Missing, " "))
+	}
+	if len(e.Extra) > 0 {
+		sb.WriteString(", unexpected: " + strings.Join(e.Extra, " "))
+	}
+	return sb.String()
+}
+
+//Validate 检查每个已翻译单元的译文是否保留了原文中的全部占位符
+func (d *Document) Validate() error {
+	var errs []error
+	for _, u := range d.Units {
+		if u.Target == "" {
+			continue
+		}
+		missing, extra := catalog.DiffPlaceholders(u.Source, u.Target)
+		if len(missing) > 0 || len(extra) > 0 {
+			errs = append(errs, &PlaceholderError{Key: u.Key, Missing: missing, Extra: extra})
+		}
+	}
+	return errors.Join(errs...)
+}